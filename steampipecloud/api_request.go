@@ -0,0 +1,108 @@
+package steampipecloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+)
+
+// getAPIJSON makes a GET request to an API path and decodes the response into
+// v. It is used for API parameters which the openapi client does not support
+// yet, e.g. the where parameter of the audit log list, and otherwise behaves
+// like the client: it uses the same servers, headers and HTTP client, and
+// returns an *APIError for error responses.
+func getAPIJSON(ctx context.Context, svc *openapi.APIClient, operation string, path string, query url.Values, v interface{}) error {
+	body, err := getAPI(ctx, svc, operation, path, query, "application/json")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid response from %s: %v", path, err)
+	}
+	return nil
+}
+
+// getAPI makes a GET request to an API path, accepting the given content
+// type, and returns the body of the response. It is used for responses which
+// the openapi client cannot decode, e.g. JSON lines.
+func getAPI(ctx context.Context, svc *openapi.APIClient, operation string, path string, query url.Values, accept string) ([]byte, error) {
+	resp, err := doAPIRequest(ctx, svc, http.MethodGet, operation, path, query, accept)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// doAPIRequest makes a request to an API path, accepting the given content
+// type, and returns the response for the caller to read and close. Error
+// responses are returned as an *APIError, with the body already closed.
+func doAPIRequest(ctx context.Context, svc *openapi.APIClient, method string, operation string, path string, query url.Values, accept string) (*http.Response, error) {
+	cfg := svc.GetConfig()
+
+	basePath, err := cfg.ServerURLWithContext(ctx, operation)
+	if err != nil {
+		return nil, err
+	}
+	requestURL := basePath + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range cfg.DefaultHeader {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Accept", accept)
+	if cfg.UserAgent != "" {
+		req.Header.Set("User-Agent", cfg.UserAgent)
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Kind:       apiErrorKindForStatus(resp.StatusCode),
+			err:        errors.New(resp.Status),
+		}
+		var model openapi.ErrorModel
+		if json.Unmarshal(body, &model) == nil && model.Status != 0 {
+			apiErr.Model = &model
+		}
+		return nil, apiErr
+	}
+
+	return resp, nil
+}
+
+// listQuery returns the query parameters for a page of a list request.
+func listQuery(nextToken *string, limit int32, filter string) url.Values {
+	query := url.Values{}
+	query.Set("limit", fmt.Sprint(limit))
+	if nextToken != nil {
+		query.Set("next_token", *nextToken)
+	}
+	if filter != "" {
+		query.Set("where", filter)
+	}
+	return query
+}
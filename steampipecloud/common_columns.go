@@ -0,0 +1,32 @@
+package steampipecloud
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// commonColumns appends the columns shared by all tables to a table's columns.
+func commonColumns(columns []*plugin.Column) []*plugin.Column {
+	return append(columns, []*plugin.Column{
+		{
+			Name:        "platform",
+			Description: "The platform which served the query, either steampipe_cloud or turbot_pipes, or unknown if the platform of a self-hosted deployment could not be discovered.",
+			Type:        proto.ColumnType_STRING,
+			Hydrate:     getPlatform,
+			Transform:   transform.FromValue(),
+		},
+	}...)
+}
+
+func getPlatform(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	endpoint, err := connectEndpoint(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("getPlatform", "connection_error", err)
+		return nil, err
+	}
+
+	return endpoint.Platform, nil
+}
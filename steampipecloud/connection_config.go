@@ -2,29 +2,69 @@ package steampipecloud
 
 import (
 	"context"
-	"errors"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
-	"strings"
+	"time"
 
 	openapiclient "github.com/turbot/steampipe-cloud-sdk-go"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/schema"
 )
 
+const (
+	// clientKeyCacheKey caches the cache key of the connection's client.
+	clientKeyCacheKey = "APIClientKey"
+	// clientKeyTTL is how long the cache key of the connection's client is
+	// kept, after which the token files and environment variables are
+	// checked again for a new token.
+	clientKeyTTL = 30 * time.Second
+	// tokenCommandClientTTL is how long a client using a token printed by
+	// token_command is kept before the command is run again, as credential
+	// helpers often issue short-lived tokens.
+	tokenCommandClientTTL = 5 * time.Minute
+)
+
 type steampipecloudConfig struct {
-	Token *string `cty:"token"`
-	Host  *string `cty:"host"`
+	Token                    *string  `cty:"token"`
+	TokenFile                *string  `cty:"token_file"`
+	TokenCommand             []string `cty:"token_command"`
+	Host                     *string  `cty:"host"`
+	IgnoreOrgForbiddenErrors *bool    `cty:"ignore_org_forbidden_errors"`
+	RequestsPerSecond        *int     `cty:"requests_per_second"`
+	RequestsBurst            *int     `cty:"requests_burst"`
+	MaxSnapshotSizeBytes     *int     `cty:"max_snapshot_size_bytes"`
 }
 
 var ConfigSchema = map[string]*schema.Attribute{
 	"token": {
 		Type: schema.TypeString,
 	},
+	"token_file": {
+		Type: schema.TypeString,
+	},
+	"token_command": {
+		Type: schema.TypeList,
+		Elem: &schema.Attribute{Type: schema.TypeString},
+	},
 	"host": {
 		Type: schema.TypeString,
 	},
+	"ignore_org_forbidden_errors": {
+		Type: schema.TypeBool,
+	},
+	"requests_per_second": {
+		Type: schema.TypeInt,
+	},
+	"requests_burst": {
+		Type: schema.TypeInt,
+	},
+	"max_snapshot_size_bytes": {
+		Type: schema.TypeInt,
+	},
 }
 
 func ConfigInstance() interface{} {
@@ -40,32 +80,113 @@ func GetConfig(connection *plugin.Connection) steampipecloudConfig {
 	return config
 }
 
-func connect(_ context.Context, d *plugin.QueryData) (*openapiclient.APIClient, error) {
+// connect returns the API client for the connection. Building a client parses
+// the server configuration and creates a new HTTP transport, so it is done
+// once per connection config and the client is cached for reuse by all
+// hydrate calls.
+func connect(ctx context.Context, d *plugin.QueryData) (*openapiclient.APIClient, error) {
 	steampipecloudConfig := GetConfig(d.Connection)
+	host := resolveHost(steampipecloudConfig)
 
-	token := os.Getenv("STEAMPIPE_CLOUD_TOKEN")
-	if steampipecloudConfig.Token != nil {
-		token = *steampipecloudConfig.Token
+	requestsPerSecond := defaultRequestsPerSecond
+	if steampipecloudConfig.RequestsPerSecond != nil {
+		requestsPerSecond = *steampipecloudConfig.RequestsPerSecond
 	}
-	if token == "" {
-		return nil, errors.New("'token' must be set in the connection configuration. Edit your connection configuration file and then restart Steampipe")
+	requestsBurst := defaultRequestsBurst
+	if steampipecloudConfig.RequestsBurst != nil {
+		requestsBurst = *steampipecloudConfig.RequestsBurst
 	}
 
-	configuration := openapiclient.NewConfiguration()
-	configuration.AddDefaultHeader("Authorization", fmt.Sprintf("Bearer %s", token))
+	// The cache key is derived from the config, so a client built for a
+	// previous config is never returned once the config has changed. It is
+	// kept for a short time rather than derived on every call, as that checks
+	// the token files on disk
+	cacheKey, err := memoizeLookupTTL(ctx, d, clientKeyCacheKey, func() (string, error) {
+		return "APIClient/" + clientConfigHash(steampipecloudConfig, host, requestsPerSecond, requestsBurst), nil
+	}, func(string) time.Duration {
+		return clientKeyTTL
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	host := os.Getenv("STEAMPIPE_CLOUD_HOST")
-	if steampipecloudConfig.Host != nil {
-		host = *steampipecloudConfig.Host
+	clientTTL := func(*openapiclient.APIClient) time.Duration {
+		if usesTokenCommand(steampipecloudConfig) {
+			return tokenCommandClientTTL
+		}
+		return defaultLookupTTL
 	}
 
-	if host != "" && !strings.Contains(host, "cloud.steampipe.io") {
-		parsedURL, parseErr := url.Parse(host)
-		if parseErr != nil {
-			return nil, fmt.Errorf(`invalid host: %v`, parseErr)
+	// The client is built once per cache key, outside of any lock shared
+	// with other connections, as a slow host or token_command must not stall
+	// hydrate calls for other connections or cached clients
+	return memoizeLookupTTL(ctx, d, cacheKey, func() (*openapiclient.APIClient, error) {
+		endpoint, err := connectEndpoint(ctx, d)
+		if err != nil {
+			return nil, err
 		}
-		if parsedURL.Host == "" {
-			return nil, errors.New(`missing protocol or host`)
+
+		// The token is only resolved when building a client, as a
+		// token_command can be slow to run
+		token, err := resolveToken(steampipecloudConfig, host)
+		if err != nil {
+			return nil, err
+		}
+
+		// The rate limiter is shared by all requests made with the client
+		var limiter *rateLimiter
+		if requestsPerSecond > 0 {
+			limiter = newRateLimiter(requestsPerSecond, requestsBurst)
+		}
+
+		// A token which is rejected may have expired or been rotated, so the
+		// client is evicted and the next call builds one with a fresh token
+		evict := func() {
+			d.ConnectionManager.Cache.Delete(cacheKey)
+			d.ConnectionManager.Cache.Delete(clientKeyCacheKey)
+		}
+
+		return newAPIClient(token, endpoint, newHTTPClient(limiter, evict))
+	}, clientTTL)
+}
+
+// clientConfigHash returns a hash of the config used to build a client, so
+// that tokens are not held in cache keys. Token files are identified by their
+// path, modification time and size rather than read on every call.
+func clientConfigHash(config steampipecloudConfig, host string, requestsPerSecond, requestsBurst int) string {
+	var token, tokenFile string
+	if config.Token != nil {
+		token = *config.Token
+	}
+	if config.TokenFile != nil {
+		tokenFile = *config.TokenFile
+	}
+	// The versions of the token files are included, so a rotated token is
+	// used once its file has been rewritten
+	var tokenFileVersions string
+	if tokenFile != "" {
+		tokenFileVersions = tokenFileVersion(tokenFile)
+	}
+	if loginTokenPath, err := steampipeLoginTokenPath(host); err == nil {
+		tokenFileVersions += " " + tokenFileVersion(loginTokenPath)
+	}
+	key := fmt.Sprintf("%q %q %q %q %q %q %d %d", token, tokenFile, tokenFileVersions, config.TokenCommand, os.Getenv("STEAMPIPE_CLOUD_TOKEN")+" "+os.Getenv("PIPES_TOKEN"), host, requestsPerSecond, requestsBurst)
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func newAPIClient(token string, endpoint *cloudEndpoint, httpClient *http.Client) (*openapiclient.APIClient, error) {
+	configuration := openapiclient.NewConfiguration()
+	configuration.AddDefaultHeader("Authorization", fmt.Sprintf("Bearer %s", token))
+	configuration.HTTPClient = httpClient
+
+	// The default servers are for Steampipe Cloud, so for any other endpoint
+	// the server URLs are rebased onto the endpoint's base URL, keeping their
+	// paths, e.g. https://pipes.turbot.com/api/v0
+	if endpoint.BaseURL != steampipeCloudURL {
+		description := "Turbot Pipes API"
+		if endpoint.Platform == platformSteampipeCloud {
+			description = "Steampipe Cloud API"
 		}
 
 		// Parse and frame the Primary Servers
@@ -75,7 +196,7 @@ func connect(_ context.Context, d *plugin.QueryData) (*openapiclient.APIClient,
 			if parseErr != nil {
 				return nil, fmt.Errorf(`invalid host: %v`, parseErr)
 			}
-			primaryServers = append(primaryServers, openapiclient.ServerConfiguration{URL: fmt.Sprintf("%s://%s%s", serverURL.Scheme, parsedURL.Host, serverURL.Path), Description: "Local API"})
+			primaryServers = append(primaryServers, openapiclient.ServerConfiguration{URL: endpoint.BaseURL + serverURL.Path, Description: description})
 		}
 		configuration.Servers = primaryServers
 
@@ -88,7 +209,7 @@ func connect(_ context.Context, d *plugin.QueryData) (*openapiclient.APIClient,
 				if parseErr != nil {
 					return nil, fmt.Errorf(`invalid host: %v`, parseErr)
 				}
-				serviceServers = append(serviceServers, openapiclient.ServerConfiguration{URL: fmt.Sprintf("%s://%s%s", serverURL.Scheme, parsedURL.Host, serverURL.Path), Description: "Local API"})
+				serviceServers = append(serviceServers, openapiclient.ServerConfiguration{URL: endpoint.BaseURL + serverURL.Path, Description: description})
 			}
 			operationServers[service] = serviceServers
 		}
@@ -99,3 +220,15 @@ func connect(_ context.Context, d *plugin.QueryData) (*openapiclient.APIClient,
 
 	return apiClient, nil
 }
+
+// newHTTPClient returns an HTTP client for the API. Listing a table across
+// workspaces makes many concurrent requests to the same host, so more idle
+// connections are kept alive for reuse than the default of 2 per host.
+// Requests are rate limited by limiter, if set, and onUnauthorized is called
+// if the API rejects the token.
+func newHTTPClient(limiter *rateLimiter, onUnauthorized func()) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 50
+	return &http.Client{Transport: &rateLimitedTransport{transport: transport, limiter: limiter, onUnauthorized: onUnauthorized}}
+}
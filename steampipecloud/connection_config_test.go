@@ -0,0 +1,182 @@
+package steampipecloud
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	openapiclient "github.com/turbot/steampipe-cloud-sdk-go"
+)
+
+func TestConnectReusesClient(t *testing.T) {
+	m := newMockCloud(t)
+	ctx := context.Background()
+
+	table := Plugin(ctx).TableMap["steampipecloud_user"]
+	d, err := m.newQueryData(t, table, nil, tableQuery{table: table.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := connect(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := connect(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("expected the client to be reused")
+	}
+
+	// a config change must not return the client built for the old config.
+	// The plugin clears the connection cache when the config changes.
+	config := m.config()
+	token := "spt_other_token"
+	config.Token = &token
+	d.Connection.Config = config
+	d.ConnectionCache.Clear(ctx)
+
+	third, err := connect(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third == first {
+		t.Error("expected a new client after the config changed")
+	}
+}
+
+func TestConnectSharesConcurrentClient(t *testing.T) {
+	m := newMockCloud(t)
+	ctx := context.Background()
+
+	table := Plugin(ctx).TableMap["steampipecloud_user"]
+	d, err := m.newQueryData(t, table, nil, tableQuery{table: table.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	clients := make(chan *openapiclient.APIClient, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := connect(ctx, d)
+			if err != nil {
+				t.Error(err)
+			}
+			clients <- client
+		}()
+	}
+	wg.Wait()
+	close(clients)
+
+	first := <-clients
+	for client := range clients {
+		if client != first {
+			t.Error("expected concurrent calls to share a single client")
+		}
+	}
+}
+
+func TestConnectRereadsRotatedTokenFile(t *testing.T) {
+	m := newFixtureCloud(t)
+	ctx := context.Background()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("spt_old_token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	table := Plugin(ctx).TableMap["steampipecloud_user"]
+	d, err := m.newQueryData(t, table, nil, tableQuery{table: table.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := m.config()
+	config.Token = nil
+	config.TokenFile = &tokenFile
+	d.Connection.Config = config
+
+	first, err := connect(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the old token has been revoked and the file rewritten with a new one,
+	// a second later so that the modification time changes on file systems
+	// with coarse timestamps
+	if err := os.WriteFile(tokenFile, []byte(mockCloudToken), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(tokenFile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	// the file is not checked on every call
+	if client, err := connect(ctx, d); err != nil || client != first {
+		t.Fatalf("expected the client to be reused until the token is rejected, got %v", err)
+	}
+	if _, _, err := first.Actors.Get(ctx).Execute(); !isAPIError(err, apiErrorUnauthorized) {
+		t.Fatalf("expected the old token to be rejected, got %v", err)
+	}
+
+	second, err := connect(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first {
+		t.Fatal("expected a new client after the token was rejected")
+	}
+	if header := second.GetConfig().DefaultHeader["Authorization"]; header != "Bearer "+mockCloudToken {
+		t.Errorf("expected the new token, got %q", header)
+	}
+	if _, _, err := second.Actors.Get(ctx).Execute(); err != nil {
+		t.Errorf("expected the new token to be accepted, got %v", err)
+	}
+}
+
+func TestConnectRerunsTokenCommandOnUnauthorized(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("token_command tests use sh")
+	}
+
+	m := newFixtureCloud(t)
+	ctx := context.Background()
+
+	// the credential helper prints an expired token the first time it runs
+	runs := filepath.Join(t.TempDir(), "runs")
+	helper := "if [ -f " + runs + " ]; then echo " + mockCloudToken + "; else touch " + runs + "; echo spt_expired_token; fi"
+
+	table := Plugin(ctx).TableMap["steampipecloud_user"]
+	d, err := m.newQueryData(t, table, nil, tableQuery{table: table.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := m.config()
+	config.Token = nil
+	config.TokenCommand = []string{"sh", "-c", helper}
+	d.Connection.Config = config
+
+	first, err := connect(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := first.Actors.Get(ctx).Execute(); !isAPIError(err, apiErrorUnauthorized) {
+		t.Fatalf("expected the expired token to be rejected, got %v", err)
+	}
+
+	second, err := connect(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := second.Actors.Get(ctx).Execute(); err != nil {
+		t.Errorf("expected the command to be run again for a new token, got %v", err)
+	}
+}
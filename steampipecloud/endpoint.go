@@ -0,0 +1,207 @@
+package steampipecloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+const (
+	platformSteampipeCloud = "steampipe_cloud"
+	platformTurbotPipes    = "turbot_pipes"
+	platformUnknown        = "unknown"
+
+	steampipeCloudURL = "https://cloud.steampipe.io"
+	turbotPipesURL    = "https://pipes.turbot.com"
+
+	// versionDiscoveryTimeout is the time allowed for the version endpoint to respond.
+	versionDiscoveryTimeout = 10 * time.Second
+	// failedDiscoveryTTL is how long an endpoint whose platform could not be
+	// discovered is cached before discovery is tried again. It is short, so a
+	// transient error does not leave the platform unknown, but discovery is
+	// not retried for every row of the platform column.
+	failedDiscoveryTTL = time.Minute
+)
+
+// knownPlatforms maps the hostnames of the hosted services to their platform.
+var knownPlatforms = map[string]string{
+	"cloud.steampipe.io": platformSteampipeCloud,
+	"pipes.turbot.com":   platformTurbotPipes,
+}
+
+// cloudEndpoint is the API deployment used by a connection.
+type cloudEndpoint struct {
+	// Platform is either steampipe_cloud, turbot_pipes or unknown, if the
+	// platform of a self-hosted deployment could not be discovered.
+	Platform string
+	// BaseURL is the URL the API paths are relative to, e.g.
+	// https://pipes.turbot.com, without a trailing slash.
+	BaseURL string
+	// Version is the API version reported by the version endpoint, if known.
+	Version string
+	// discoveryFailed is true if the version endpoint could not be reached.
+	discoveryFailed bool
+}
+
+// resolveHost returns the host for the connection, taken from the host config
+// argument, the STEAMPIPE_CLOUD_HOST or PIPES_HOST environment variables, or
+// the Turbot Pipes host if only a PIPES_TOKEN is set. An empty host means
+// Steampipe Cloud.
+func resolveHost(config steampipecloudConfig) string {
+	if config.Host != nil && *config.Host != "" {
+		return *config.Host
+	}
+	if host := os.Getenv("STEAMPIPE_CLOUD_HOST"); host != "" {
+		return host
+	}
+	if host := os.Getenv("PIPES_HOST"); host != "" {
+		return host
+	}
+	if os.Getenv("PIPES_TOKEN") != "" && os.Getenv("STEAMPIPE_CLOUD_TOKEN") == "" {
+		return turbotPipesURL
+	}
+	return ""
+}
+
+// parseEndpointURL returns the base URL for a host, which may be given with
+// or without a scheme, e.g. "pipes.turbot.com", and may include the API path.
+func parseEndpointURL(host string) (*url.URL, error) {
+	if host == "" {
+		host = steampipeCloudURL
+	}
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	parsedURL, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf(`invalid host: %v`, err)
+	}
+	if parsedURL.Host == "" {
+		return nil, errors.New(`missing protocol or host`)
+	}
+	parsedURL.Path = strings.TrimSuffix(strings.TrimSuffix(parsedURL.Path, "/"), "/api/v0")
+	parsedURL.RawQuery = ""
+	parsedURL.Fragment = ""
+	return parsedURL, nil
+}
+
+// hostPlatform returns the platform of one of the hosted services, or
+// unknown for any other host.
+func hostPlatform(host string) string {
+	parsedURL, err := parseEndpointURL(host)
+	if err != nil {
+		return platformUnknown
+	}
+	if platform, ok := knownPlatforms[parsedURL.Hostname()]; ok {
+		return platform
+	}
+	return platformUnknown
+}
+
+// connectEndpoint returns the API endpoint for the connection. The platform of
+// a host other than the hosted services is discovered using the API's version
+// endpoint, once per host, or again after failedDiscoveryTTL if the version
+// endpoint could not be reached.
+func connectEndpoint(ctx context.Context, d *plugin.QueryData) (*cloudEndpoint, error) {
+	host := resolveHost(GetConfig(d.Connection))
+
+	return memoizeLookupTTL(ctx, d, "Endpoint/"+host, func() (*cloudEndpoint, error) {
+		return discoverEndpoint(ctx, host)
+	}, endpointCacheTTL)
+}
+
+// endpointCacheTTL returns how long an endpoint is cached for.
+func endpointCacheTTL(endpoint *cloudEndpoint) time.Duration {
+	if endpoint.discoveryFailed {
+		return failedDiscoveryTTL
+	}
+	return defaultLookupTTL
+}
+
+func discoverEndpoint(ctx context.Context, host string) (*cloudEndpoint, error) {
+	parsedURL, err := parseEndpointURL(host)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &cloudEndpoint{
+		Platform: hostPlatform(host),
+		BaseURL:  parsedURL.String(),
+	}
+	if endpoint.Platform != platformUnknown {
+		return endpoint, nil
+	}
+
+	// A self-hosted deployment, or a host other than the hosted services
+	version, err := getAPIVersion(ctx, endpoint.BaseURL)
+	if err != nil {
+		// The API may still be usable, so the platform is left unknown rather
+		// than failing the query
+		log.Printf("[WARN] steampipecloud: unable to discover the platform of %s: %v", endpoint.BaseURL, err)
+		endpoint.discoveryFailed = true
+		return endpoint, nil
+	}
+
+	endpoint.Version = version.Version
+	for _, name := range []string{version.Platform, version.Product} {
+		name = strings.ToLower(name)
+		switch {
+		case strings.Contains(name, "pipes"):
+			endpoint.Platform = platformTurbotPipes
+		case strings.Contains(name, "steampipe"):
+			endpoint.Platform = platformSteampipeCloud
+		}
+		if endpoint.Platform != platformUnknown {
+			break
+		}
+	}
+
+	return endpoint, nil
+}
+
+// apiVersion is the response of the API's version endpoint.
+type apiVersion struct {
+	Version  string `json:"version"`
+	Platform string `json:"platform"`
+	Product  string `json:"product"`
+}
+
+func getAPIVersion(ctx context.Context, baseURL string) (*apiVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, versionDiscoveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/v0/version", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	// The version endpoint is public, so no token is sent
+	client := newHTTPClient(nil, nil)
+	client.Timeout = versionDiscoveryTimeout
+	defer client.CloseIdleConnections()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("version endpoint returned %s", resp.Status)
+	}
+
+	var version apiVersion
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return nil, fmt.Errorf("invalid version endpoint response: %v", err)
+	}
+	return &version, nil
+}
@@ -0,0 +1,87 @@
+package steampipecloud
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestDiscoverEndpointKnownHosts(t *testing.T) {
+	cases := []struct {
+		host     string
+		platform string
+		baseURL  string
+	}{
+		{"", platformSteampipeCloud, "https://cloud.steampipe.io"},
+		{"https://cloud.steampipe.io/", platformSteampipeCloud, "https://cloud.steampipe.io"},
+		{"pipes.turbot.com", platformTurbotPipes, "https://pipes.turbot.com"},
+		{"https://pipes.turbot.com/api/v0", platformTurbotPipes, "https://pipes.turbot.com"},
+	}
+	for _, tc := range cases {
+		endpoint, err := discoverEndpoint(context.Background(), tc.host)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.host, err)
+		}
+		if endpoint.Platform != tc.platform || endpoint.BaseURL != tc.baseURL {
+			t.Errorf("%q: expected %s at %s, got %s at %s", tc.host, tc.platform, tc.baseURL, endpoint.Platform, endpoint.BaseURL)
+		}
+	}
+}
+
+func TestResolveHost(t *testing.T) {
+	t.Setenv("STEAMPIPE_CLOUD_HOST", "")
+	t.Setenv("STEAMPIPE_CLOUD_TOKEN", "")
+	t.Setenv("PIPES_HOST", "")
+	t.Setenv("PIPES_TOKEN", "tpt_token")
+	if host := resolveHost(steampipecloudConfig{}); host != turbotPipesURL {
+		t.Errorf("expected a PIPES_TOKEN to default to Turbot Pipes, got %q", host)
+	}
+
+	t.Setenv("PIPES_HOST", "https://pipes.example.com")
+	if host := resolveHost(steampipecloudConfig{}); host != "https://pipes.example.com" {
+		t.Errorf("expected PIPES_HOST, got %q", host)
+	}
+
+	configHost := "https://cloud.steampipe.io"
+	if host := resolveHost(steampipecloudConfig{Host: &configHost}); host != configHost {
+		t.Errorf("expected the host config argument, got %q", host)
+	}
+}
+
+func TestPlatformDiscovery(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.set("version", apiVersion{Version: "1.0.0", Product: "Turbot Pipes"})
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0]["platform"] != platformTurbotPipes {
+		t.Errorf("expected platform %s, got %v", platformTurbotPipes, rows)
+	}
+	if count := m.requestCount("version"); count != 1 {
+		t.Errorf("expected the platform to be discovered once, got %d requests", count)
+	}
+}
+
+func TestPlatformDiscoveryFailureCachedBriefly(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.set("version", apiVersion{Version: "1.0.0", Product: "Turbot Pipes"})
+	m.fail("version", http.StatusServiceUnavailable)
+
+	endpoint, err := discoverEndpoint(context.Background(), m.server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint.Platform != platformUnknown || endpointCacheTTL(endpoint) != failedDiscoveryTTL {
+		t.Errorf("expected an unknown platform cached for %s, got %s cached for %s", failedDiscoveryTTL, endpoint.Platform, endpointCacheTTL(endpoint))
+	}
+
+	endpoint, err = discoverEndpoint(context.Background(), m.server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint.Platform != platformTurbotPipes || endpointCacheTTL(endpoint) != defaultLookupTTL {
+		t.Errorf("expected the discovered platform cached for %s, got %s cached for %s", defaultLookupTTL, endpoint.Platform, endpointCacheTTL(endpoint))
+	}
+}
@@ -2,28 +2,166 @@ package steampipecloud
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"strconv"
 	"strings"
 
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 )
 
-func shouldIgnoreErrors(notFoundErrors []string) plugin.ErrorPredicateWithContext {
-	return func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, err error) bool {
-		for _, pattern := range notFoundErrors {
-			// handle not found error
-			if strings.Contains(err.Error(), pattern) {
-				return true
-			}
+// apiErrorKind classifies an API error by its HTTP status.
+type apiErrorKind string
+
+const (
+	apiErrorUnknown      apiErrorKind = "Unknown"
+	apiErrorUnauthorized apiErrorKind = "Unauthorized"
+	apiErrorForbidden    apiErrorKind = "Forbidden"
+	apiErrorNotFound     apiErrorKind = "NotFound"
+	apiErrorRateLimited  apiErrorKind = "RateLimited"
+	apiErrorServerError  apiErrorKind = "ServerError"
+)
+
+// APIError is an error response from the Steampipe Cloud API.
+type APIError struct {
+	StatusCode int
+	Kind       apiErrorKind
+	// Model is the error body returned by the API, if it could be decoded.
+	Model *openapi.ErrorModel
+	err   error
+}
+
+func (e *APIError) Error() string {
+	if e.Model != nil && e.Model.Detail != nil && *e.Model.Detail != "" {
+		return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), *e.Model.Detail)
+	}
+	return e.err.Error()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+// asAPIError returns the API error held in err, if there is one. Errors
+// returned by the openapi client are unwrapped to their HTTP status and error
+// body; any other error, e.g. a network failure, returns false.
+func asAPIError(err error) (*APIError, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+
+	var genericErr openapi.GenericOpenAPIError
+	var genericErrPtr *openapi.GenericOpenAPIError
+	switch {
+	case errors.As(err, &genericErr):
+	case errors.As(err, &genericErrPtr) && genericErrPtr != nil:
+		genericErr = *genericErrPtr
+	default:
+		return nil, false
+	}
+
+	apiErr = &APIError{err: err}
+
+	// The client only decodes the error body for some status codes, so fall
+	// back to decoding it here
+	switch model := genericErr.Model().(type) {
+	case openapi.ErrorModel:
+		apiErr.Model = &model
+	case *openapi.ErrorModel:
+		apiErr.Model = model
+	default:
+		var body openapi.ErrorModel
+		if json.Unmarshal(genericErr.Body(), &body) == nil && body.Status != 0 {
+			apiErr.Model = &body
 		}
+	}
+
+	if apiErr.Model != nil {
+		apiErr.StatusCode = int(apiErr.Model.Status)
+	} else {
+		// The error message is the status line of the response, e.g. "404 Not Found"
+		statusCode, _ := strconv.Atoi(strings.SplitN(genericErr.Error(), " ", 2)[0])
+		apiErr.StatusCode = statusCode
+	}
+	apiErr.Kind = apiErrorKindForStatus(apiErr.StatusCode)
+
+	return apiErr, true
+}
+
+func apiErrorKindForStatus(statusCode int) apiErrorKind {
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		return apiErrorUnauthorized
+	case statusCode == http.StatusForbidden:
+		return apiErrorForbidden
+	case statusCode == http.StatusNotFound:
+		return apiErrorNotFound
+	case statusCode == http.StatusTooManyRequests:
+		return apiErrorRateLimited
+	case statusCode >= 500 && statusCode <= 599:
+		return apiErrorServerError
+	}
+	return apiErrorUnknown
+}
+
+// isAPIError returns true if err is an API error of one of the given kinds.
+func isAPIError(err error, kinds ...apiErrorKind) bool {
+	apiErr, ok := asAPIError(err)
+	if !ok {
 		return false
 	}
+	for _, kind := range kinds {
+		if apiErr.Kind == kind {
+			return true
+		}
+	}
+	return false
 }
 
+// shouldIgnoreErrors returns an ignore policy for API errors of the given kinds.
+func shouldIgnoreErrors(kinds ...apiErrorKind) plugin.ErrorPredicateWithContext {
+	return func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, err error) bool {
+		return isAPIError(err, kinds...)
+	}
+}
+
+// shouldIgnoreOrgErrors is the ignore policy for org resources. Not found
+// errors are always ignored, and forbidden errors are ignored if the
+// connection is configured to skip orgs the token cannot access.
+func shouldIgnoreOrgErrors(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, err error) bool {
+	if isAPIError(err, apiErrorNotFound) {
+		return true
+	}
+	config := GetConfig(d.Connection)
+	if config.IgnoreOrgForbiddenErrors != nil && *config.IgnoreOrgForbiddenErrors {
+		return isAPIError(err, apiErrorForbidden)
+	}
+	return false
+}
+
+// shouldRetryError retries rate limited requests and server errors. The
+// retries use the backoff of the RetryConfig they are passed in.
 func shouldRetryError(err error) bool {
-	if strings.Contains(err.Error(), "429") {
+	apiErr, ok := asAPIError(err)
+	if !ok {
+		return false
+	}
+	switch apiErr.Kind {
+	case apiErrorRateLimited:
 		log.Printf("[WARN] Received Rate Limit Error")
 		return true
+	case apiErrorServerError:
+		log.Printf("[WARN] Received Server Error: %d", apiErr.StatusCode)
+		return true
 	}
 	return false
 }
@@ -0,0 +1,174 @@
+package steampipecloud
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// filterTimeFormat is the timestamp format understood by the API's where parameter.
+const filterTimeFormat = "2006-01-02 15:04:05.00000"
+
+// filterOperators maps the qual operators which can be pushed down to their
+// filter expression equivalents.
+var filterOperators = map[string]string{
+	"=":  "=",
+	"<>": "<>",
+	">":  ">",
+	">=": ">=",
+	"<":  "<",
+	"<=": "<=",
+}
+
+var filterColumnRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// queryFilter builds the filter expression passed to the API's where
+// parameter. Values are always rendered as escaped literals, so a qual value
+// can never change the structure of the expression.
+type queryFilter struct {
+	clauses []string
+}
+
+// add appends a "column operator value" clause to the filter.
+func (f *queryFilter) add(column string, operator string, value *proto.QualValue) error {
+	if !filterColumnRegex.MatchString(column) {
+		return fmt.Errorf("invalid filter column %q", column)
+	}
+
+	op, ok := filterOperators[operator]
+	if !ok {
+		return fmt.Errorf("unsupported filter operator %q for column %s", operator, column)
+	}
+
+	// Lists are passed when the query uses "in"
+	if list := value.GetListValue(); list != nil {
+		if op != "=" && op != "<>" {
+			return fmt.Errorf("unsupported filter operator %q for a list of values for column %s", operator, column)
+		}
+		var literals []string
+		for _, v := range list.Values {
+			literal, err := filterLiteral(v)
+			if err != nil {
+				return fmt.Errorf("invalid filter value for column %s: %w", column, err)
+			}
+			literals = append(literals, literal)
+		}
+		if len(literals) == 0 {
+			return nil
+		}
+		listOp := "in"
+		if op == "<>" {
+			listOp = "not in"
+		}
+		f.clauses = append(f.clauses, fmt.Sprintf("%s %s (%s)", column, listOp, strings.Join(literals, ", ")))
+		return nil
+	}
+
+	literal, err := filterLiteral(value)
+	if err != nil {
+		return fmt.Errorf("invalid filter value for column %s: %w", column, err)
+	}
+	f.clauses = append(f.clauses, fmt.Sprintf("%s %s %s", column, op, literal))
+	return nil
+}
+
+// stringFilterValue wraps a string computed by the plugin, rather than taken
+// from a qual, so that it can be added to a filter.
+func stringFilterValue(s string) *proto.QualValue {
+	return &proto.QualValue{Value: &proto.QualValue_StringValue{StringValue: s}}
+}
+
+// timeFilterValue wraps a time computed by the plugin, rather than taken from
+// a qual, so that it can be added to a filter.
+func timeFilterValue(t time.Time) *proto.QualValue {
+	return &proto.QualValue{Value: &proto.QualValue_TimestampValue{TimestampValue: timestamppb.New(t)}}
+}
+
+// addRaw appends a user supplied filter expression, such as the value of a
+// query_where qual, as-is. It is parenthesised so that it cannot alter the
+// meaning of the other clauses.
+func (f *queryFilter) addRaw(expression string) {
+	if strings.TrimSpace(expression) == "" {
+		return
+	}
+	f.clauses = append(f.clauses, "("+expression+")")
+}
+
+// String returns the clauses joined with "and".
+func (f *queryFilter) String() string {
+	return strings.Join(f.clauses, " and ")
+}
+
+// filterLiteral renders a qual value as a literal for use in a filter expression.
+func filterLiteral(value *proto.QualValue) (string, error) {
+	switch v := value.GetValue().(type) {
+	case *proto.QualValue_StringValue:
+		return quoteFilterString(v.StringValue)
+	case *proto.QualValue_Int64Value:
+		return strconv.FormatInt(v.Int64Value, 10), nil
+	case *proto.QualValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'f', -1, 64), nil
+	case *proto.QualValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue), nil
+	case *proto.QualValue_TimestampValue:
+		t := time.Unix(v.TimestampValue.Seconds, int64(v.TimestampValue.Nanos)).UTC()
+		return quoteFilterString(t.Format(filterTimeFormat))
+	default:
+		return "", fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// quoteFilterString returns s as a single quoted string literal, doubling any
+// embedded quotes.
+func quoteFilterString(s string) (string, error) {
+	if strings.ContainsRune(s, 0) {
+		return "", fmt.Errorf("string values must not contain NUL characters")
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'", nil
+}
+
+// buildQueryFilter returns the filter expression for all quals on the table's
+// list key columns, except for those named in skip which are handled by the
+// caller. If a query_where qual was passed it is appended to the filter.
+func buildQueryFilter(d *plugin.QueryData, skip ...string) (string, error) {
+	var filter queryFilter
+
+	for _, keyQual := range d.Table.List.KeyColumns {
+		if keyQual.Name == "query_where" || contains(skip, keyQual.Name) {
+			continue
+		}
+		filterQual := d.Quals[keyQual.Name]
+		if filterQual == nil {
+			continue
+		}
+		for _, qual := range filterQual.Quals {
+			if qual.Value == nil {
+				continue
+			}
+			if err := filter.add(keyQual.Name, qual.Operator, qual.Value); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if d.EqualsQuals["query_where"] != nil {
+		filter.addRaw(d.EqualsQuals["query_where"].GetStringValue())
+	}
+
+	return filter.String(), nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,328 @@
+package steampipecloud
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/dgraph-io/ristretto"
+	"github.com/eko/gocache/v3/cache"
+	"github.com/eko/gocache/v3/store"
+	"github.com/hashicorp/go-hclog"
+	"github.com/turbot/steampipe-plugin-sdk/v5/connection"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/quals"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// The SDK only exposes query execution over gRPC, so tableQuery drives a
+// table's hydrate functions directly, in the same way the SDK does: the list
+// (or get) hydrate is called with the key column quals, parent list items are
+// fed to the child list hydrate, each column hydrate is called once per row
+// and column transforms are applied to the results. Hydrate errors are
+// filtered through the ignore config.
+
+// testRow is a single result row, keyed by column name.
+type testRow map[string]interface{}
+
+// tableQuery describes a query against a single table.
+type tableQuery struct {
+	table string
+	quals []*quals.Qual
+	limit *int64
+}
+
+func equalsQual(column string, value string) *quals.Qual {
+	return &quals.Qual{Column: column, Operator: "=", Value: &proto.QualValue{Value: &proto.QualValue_StringValue{StringValue: value}}}
+}
+
+func operatorQual(column string, operator string, value *proto.QualValue) *quals.Qual {
+	return &quals.Qual{Column: column, Operator: operator, Value: value}
+}
+
+// list runs the table's list hydrate against the mock and returns the rows.
+func (m *mockCloud) list(q tableQuery) ([]testRow, error) {
+	return m.run(q, false)
+}
+
+// get runs the table's get hydrate against the mock and returns the row, if any.
+func (m *mockCloud) get(q tableQuery) (testRow, error) {
+	rows, err := m.run(q, true)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return rows[0], nil
+}
+
+func (m *mockCloud) run(q tableQuery, isGet bool) ([]testRow, error) {
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	p := Plugin(ctx)
+	table, ok := p.TableMap[q.table]
+	if !ok {
+		return nil, fmt.Errorf("unknown table %s", q.table)
+	}
+	table.Plugin = p
+
+	var keyColumns plugin.KeyColumnSlice
+	if isGet {
+		if table.Get == nil {
+			return nil, fmt.Errorf("table %s has no get config", q.table)
+		}
+		keyColumns = table.Get.KeyColumns
+	} else {
+		keyColumns = table.List.KeyColumns
+	}
+
+	d, err := m.newQueryData(m.t, table, keyColumns, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var rows []testRow
+	var rowErr error
+	emit := func(item, parent interface{}) {
+		row, err := buildTestRow(ctx, d, p, table, item, parent)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			rowErr = err
+			return
+		}
+		rows = append(rows, row)
+		incrementRowsStreamed(d)
+	}
+
+	if isGet {
+		item, err := table.Get.Hydrate(ctx, d, &plugin.HydrateData{})
+		if err != nil && !shouldIgnoreTestError(ctx, d, p, err) {
+			return nil, err
+		}
+		if item != nil {
+			emit(item, nil)
+		}
+		return rows, rowErr
+	}
+
+	if table.List.ParentHydrate == nil {
+		d.StreamListItem = func(ctx context.Context, items ...interface{}) {
+			for _, item := range items {
+				emit(item, nil)
+			}
+		}
+		if _, err := table.List.Hydrate(ctx, d, &plugin.HydrateData{}); err != nil && !shouldIgnoreTestError(ctx, d, p, err) {
+			return nil, err
+		}
+		return rows, rowErr
+	}
+
+	// Parent-child list: each parent item is passed to the child hydrate,
+	// which streams the leaf rows.
+	var parents []interface{}
+	d.StreamListItem = func(ctx context.Context, items ...interface{}) {
+		parents = append(parents, items...)
+	}
+	if _, err := table.List.ParentHydrate(ctx, d, &plugin.HydrateData{}); err != nil && !shouldIgnoreTestError(ctx, d, p, err) {
+		return nil, err
+	}
+	for _, parent := range parents {
+		parent := parent
+		d.StreamListItem = func(ctx context.Context, items ...interface{}) {
+			for _, item := range items {
+				emit(item, parent)
+			}
+		}
+		if _, err := table.List.Hydrate(ctx, d, &plugin.HydrateData{Item: parent}); err != nil && !shouldIgnoreTestError(ctx, d, p, err) {
+			return nil, err
+		}
+		if d.RowsRemaining(ctx) == 0 {
+			break
+		}
+	}
+	return rows, rowErr
+}
+
+func (m *mockCloud) newQueryData(t *testing.T, table *plugin.Table, keyColumns plugin.KeyColumnSlice, q tableQuery) (*plugin.QueryData, error) {
+	qualMap := plugin.KeyColumnQualMap{}
+	for _, qual := range q.quals {
+		if keyColumns.Find(qual.Column) == nil {
+			// not a key column, so postgres would filter the rows instead
+			continue
+		}
+		if qualMap[qual.Column] == nil {
+			qualMap[qual.Column] = &plugin.KeyColumnQuals{Name: qual.Column}
+		}
+		qualMap[qual.Column].Quals = append(qualMap[qual.Column].Quals, qual)
+	}
+
+	var anyOf []string
+	anyOfSatisfied := false
+	for _, keyColumn := range keyColumns {
+		_, present := qualMap[keyColumn.Name]
+		switch keyColumn.Require {
+		case plugin.Required, "":
+			if !present {
+				return nil, fmt.Errorf("table %s requires a qual on %s", table.Name, keyColumn.Name)
+			}
+		case plugin.AnyOf:
+			anyOf = append(anyOf, keyColumn.Name)
+			anyOfSatisfied = anyOfSatisfied || present
+		}
+	}
+	if len(anyOf) > 0 && !anyOfSatisfied {
+		return nil, fmt.Errorf("table %s requires a qual on one of %v", table.Name, anyOf)
+	}
+
+	var columns []string
+	for _, column := range table.Columns {
+		columns = append(columns, column.Name)
+	}
+
+	connectionCache, err := newTestConnectionCache(t.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	config := m.config()
+	d := &plugin.QueryData{
+		Table:             table,
+		EqualsQuals:       plugin.KeyColumnEqualsQualMap(qualMap.ToEqualsQualValueMap()),
+		Quals:             qualMap,
+		QueryContext:      &plugin.QueryContext{Columns: columns, Limit: q.limit},
+		Connection:        &plugin.Connection{Name: t.Name(), Config: config},
+		ConnectionManager: connection.NewManager(connectionCache),
+		ConnectionCache:   connectionCache,
+	}
+
+	rowsRequired := int64(math.MaxInt32)
+	if q.limit != nil {
+		rowsRequired = *q.limit
+	}
+	setQueryStatus(d, rowsRequired)
+
+	return d, nil
+}
+
+func newTestConnectionCache(connectionName string) (*connection.ConnectionCache, error) {
+	ristrettoCache, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: 1000,
+		MaxCost:     100000,
+		BufferItems: 64,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return connection.NewConnectionCache(connectionName, cache.New[any](store.NewRistretto(ristrettoCache))), nil
+}
+
+// buildTestRow calls the hydrate functions for each column and applies the
+// column transforms.
+func buildTestRow(ctx context.Context, d *plugin.QueryData, p *plugin.Plugin, table *plugin.Table, item, parent interface{}) (testRow, error) {
+	hydrateResults := map[string]interface{}{}
+	row := testRow{}
+
+	for _, column := range table.Columns {
+		hydrateItem := item
+		if column.Hydrate != nil {
+			name := runtime.FuncForPC(reflect.ValueOf(column.Hydrate).Pointer()).Name()
+			result, ok := hydrateResults[name]
+			if !ok {
+				var err error
+				result, err = column.Hydrate(ctx, d, &plugin.HydrateData{Item: item, ParentItem: parent, HydrateResults: hydrateResults})
+				if err != nil {
+					if !shouldIgnoreTestError(ctx, d, p, err) {
+						return nil, fmt.Errorf("column %s: %w", column.Name, err)
+					}
+					result = nil
+				}
+				hydrateResults[name] = result
+			}
+			hydrateItem = result
+		}
+
+		if isNilValue(hydrateItem) {
+			row[column.Name] = nil
+			continue
+		}
+
+		columnTransforms := column.Transform
+		if columnTransforms == nil {
+			columnTransforms = p.DefaultTransform
+		}
+		value, err := columnTransforms.Execute(ctx, &transform.TransformData{
+			HydrateItem:    hydrateItem,
+			HydrateResults: hydrateResults,
+			ColumnName:     column.Name,
+			KeyColumnQuals: d.Quals.ToQualMap(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", column.Name, err)
+		}
+		row[column.Name] = dereference(value)
+	}
+
+	return row, nil
+}
+
+// shouldIgnoreTestError applies the table's ignore config, defaulting to the
+// plugin's, as the SDK does.
+func shouldIgnoreTestError(ctx context.Context, d *plugin.QueryData, p *plugin.Plugin, err error) bool {
+	ignoreConfig := p.DefaultIgnoreConfig
+	if d.Table.DefaultIgnoreConfig != nil {
+		ignoreConfig = d.Table.DefaultIgnoreConfig
+	}
+	return ignoreConfig.ShouldIgnoreErrorFunc(ctx, d, &plugin.HydrateData{}, err)
+}
+
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func dereference(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+// The query status tracks the rows required and streamed for the limit, and
+// is unexported, so it is populated using reflection.
+
+func setQueryStatus(d *plugin.QueryData, rowsRequired int64) {
+	field := reflect.ValueOf(d).Elem().FieldByName("queryStatus")
+	status := reflect.New(field.Type().Elem())
+	setUnexportedInt(status.Elem().FieldByName("rowsRequired"), rowsRequired)
+	reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Set(status)
+}
+
+func incrementRowsStreamed(d *plugin.QueryData) {
+	streamed := reflect.ValueOf(d).Elem().FieldByName("queryStatus").Elem().FieldByName("rowsStreamed")
+	setUnexportedInt(streamed, streamed.Int()+1)
+}
+
+func setUnexportedInt(field reflect.Value, value int64) {
+	reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().SetInt(value)
+}
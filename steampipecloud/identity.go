@@ -0,0 +1,512 @@
+package steampipecloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+const (
+	identityTypeUser = "user"
+	identityTypeOrg  = "org"
+)
+
+// Identity is a user or org which owns resources in Steampipe Cloud.
+type Identity struct {
+	Id     string `json:"id"`
+	Handle string `json:"handle"`
+	Type   string `json:"type"`
+}
+
+// IsUser returns true if the identity is a user rather than an org.
+func (i *Identity) IsUser() bool {
+	return i.Type == identityTypeUser
+}
+
+// resolveIdentity returns the identity for a user or org handle or id.
+// The identity type is always taken from the API, never inferred from the
+// format of the handle or id. Resolved identities are cached per connection
+// under both their handle and id.
+func resolveIdentity(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, handleOrId string) (*Identity, error) {
+	if handleOrId == "" {
+		return nil, errors.New("an identity handle or id must be provided")
+	}
+
+	return memoizeLookup(ctx, d, "Identity/"+handleOrId, func() (*Identity, error) {
+		// The authenticated user is cached already, so avoid an API call for it
+		getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
+		commonData, err := getUserIdentityCached(ctx, d, h)
+		if err != nil {
+			plugin.Logger(ctx).Error("resolveIdentity", "getUserIdentityCached", err)
+			return nil, err
+		}
+		user := commonData.(openapi.User)
+
+		if handleOrId == user.Id || handleOrId == user.Handle {
+			return &Identity{Id: user.Id, Handle: user.Handle, Type: identityTypeUser}, nil
+		}
+
+		svc, err := connect(ctx, d)
+		if err != nil {
+			plugin.Logger(ctx).Error("resolveIdentity", "connection_error", err)
+			return nil, err
+		}
+
+		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+			resp, _, err := svc.Identities.Get(ctx, handleOrId).Execute()
+			return resp, err
+		}
+
+		response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
+		if err != nil {
+			plugin.Logger(ctx).Error("resolveIdentity", "get", err)
+			return nil, err
+		}
+
+		resp := response.(openapi.Identity)
+		identity := &Identity{Id: resp.Id, Handle: resp.Handle, Type: resp.Type}
+
+		// cache under the other key too, so it resolves without another lookup
+		if handleOrId == identity.Handle {
+			d.ConnectionManager.Cache.Set("Identity/"+identity.Id, identity)
+		} else {
+			d.ConnectionManager.Cache.Set("Identity/"+identity.Handle, identity)
+		}
+
+		return identity, nil
+	})
+}
+
+// workspaceFromItem returns the workspace held in a hydrate item. Workspaces
+// listed via the actor are pointers, while those listed for an identity are
+// values, so both are accepted.
+func workspaceFromItem(item interface{}) *openapi.Workspace {
+	switch w := item.(type) {
+	case openapi.Workspace:
+		return &w
+	case *openapi.Workspace:
+		return w
+	}
+	return nil
+}
+
+// resolveIdentityWorkspace returns the identity and workspace handle for a
+// workspace level resource. When listing, the workspace is available as the
+// parent item; for get calls it is looked up using the workspace id, and
+// cached per connection.
+func resolveIdentityWorkspace(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, identityId, workspaceId string) (*Identity, string, error) {
+	client, err := connectIdentity(ctx, d, h, identityId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if workspace := workspaceFromItem(h.ParentItem); workspace != nil {
+		return client.identity, workspace.Handle, nil
+	}
+
+	workspaceHandle, err := memoizeLookup(ctx, d, "WorkspaceHandle/"+workspaceId, func() (string, error) {
+		workspace, err := getWorkspaceById(ctx, d, h, client, workspaceId)
+		if err != nil {
+			return "", err
+		}
+		return workspace.Handle, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return client.identity, workspaceHandle, nil
+}
+
+// getWorkspaceById returns the identity's workspace with the given id. The
+// API only gets workspaces by handle, so the identity's workspaces are listed
+// to find it. A not found API error is returned if there is no such workspace.
+func getWorkspaceById(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, client *identityClient, workspaceId string) (openapi.Workspace, error) {
+	var workspace *openapi.Workspace
+	err := forEachItem(ctx, d, h, defaultMaxResults, client.listWorkspaces, func(item openapi.Workspace) bool {
+		if item.Id == workspaceId {
+			workspace = &item
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return openapi.Workspace{}, err
+	}
+	if workspace == nil {
+		return openapi.Workspace{}, &APIError{
+			StatusCode: http.StatusNotFound,
+			Kind:       apiErrorNotFound,
+			err:        fmt.Errorf("workspace %s not found for identity %s", workspaceId, client.identity.Handle),
+		}
+	}
+	return *workspace, nil
+}
+
+// identityClient scopes API calls to a single identity, routing each call to
+// the user or org flavour of the API depending on the identity type.
+type identityClient struct {
+	svc      *openapi.APIClient
+	identity *Identity
+}
+
+// connectIdentity resolves the given handle or id and returns a client scoped to it.
+func connectIdentity(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, handleOrId string) (*identityClient, error) {
+	identity, err := resolveIdentity(ctx, d, h, handleOrId)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := connect(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identityClient{svc: svc, identity: identity}, nil
+}
+
+//// IDENTITY RESOURCES
+
+func (c *identityClient) listWorkspaces(ctx context.Context, nextToken *string, limit int32) ([]openapi.Workspace, *string, error) {
+	var resp openapi.ListWorkspacesResponse
+	var err error
+	if c.identity.IsUser() {
+		req := c.svc.UserWorkspaces.List(ctx, c.identity.Handle).Limit(limit)
+		if nextToken != nil {
+			req = req.NextToken(*nextToken)
+		}
+		resp, _, err = req.Execute()
+	} else {
+		req := c.svc.OrgWorkspaces.List(ctx, c.identity.Handle).Limit(limit)
+		if nextToken != nil {
+			req = req.NextToken(*nextToken)
+		}
+		resp, _, err = req.Execute()
+	}
+	return resp.GetItems(), resp.NextToken, err
+}
+
+func (c *identityClient) getWorkspace(ctx context.Context, workspaceHandle string) (openapi.Workspace, error) {
+	if c.identity.IsUser() {
+		resp, _, err := c.svc.UserWorkspaces.Get(ctx, c.identity.Handle, workspaceHandle).Execute()
+		return resp, err
+	}
+	resp, _, err := c.svc.OrgWorkspaces.Get(ctx, c.identity.Handle, workspaceHandle).Execute()
+	return resp, err
+}
+
+func (c *identityClient) listConnections(ctx context.Context, nextToken *string, limit int32) ([]openapi.Connection, *string, error) {
+	var resp openapi.ListConnectionsResponse
+	var err error
+	if c.identity.IsUser() {
+		req := c.svc.UserConnections.List(ctx, c.identity.Handle).Limit(limit)
+		if nextToken != nil {
+			req = req.NextToken(*nextToken)
+		}
+		resp, _, err = req.Execute()
+	} else {
+		req := c.svc.OrgConnections.List(ctx, c.identity.Handle).Limit(limit)
+		if nextToken != nil {
+			req = req.NextToken(*nextToken)
+		}
+		resp, _, err = req.Execute()
+	}
+	return resp.GetItems(), resp.NextToken, err
+}
+
+func (c *identityClient) getConnection(ctx context.Context, connectionHandle string) (openapi.Connection, error) {
+	if c.identity.IsUser() {
+		resp, _, err := c.svc.UserConnections.Get(ctx, c.identity.Handle, connectionHandle).Execute()
+		return resp, err
+	}
+	resp, _, err := c.svc.OrgConnections.Get(ctx, c.identity.Handle, connectionHandle).Execute()
+	return resp, err
+}
+
+// listAuditLogs lists the identity's audit logs matching filter. The openapi
+// client does not support the where parameter of the audit log list, so the
+// request is made directly.
+func (c *identityClient) listAuditLogs(filter string) listPageFunc[openapi.AuditRecord] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]openapi.AuditRecord, *string, error) {
+		var resp openapi.ListAuditLogsResponse
+		var err error
+		query := listQuery(nextToken, limit, filter)
+		if c.identity.IsUser() {
+			err = getAPIJSON(ctx, c.svc, "UsersService.ListAuditLogs", "/user/"+url.PathEscape(c.identity.Handle)+"/audit_log", query, &resp)
+		} else {
+			err = getAPIJSON(ctx, c.svc, "OrgsService.ListAuditLogs", "/org/"+url.PathEscape(c.identity.Handle)+"/audit_log", query, &resp)
+		}
+		return resp.GetItems(), resp.NextToken, err
+	}
+}
+
+// listProcesses lists the identity's processes matching filter. The openapi
+// client does not support the where parameter of the process list, so the
+// request is made directly.
+func (c *identityClient) listProcesses(filter string) listPageFunc[openapi.SpProcess] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]openapi.SpProcess, *string, error) {
+		var resp openapi.ListProcessesResponse
+		var err error
+		query := listQuery(nextToken, limit, filter)
+		if c.identity.IsUser() {
+			err = getAPIJSON(ctx, c.svc, "UserProcessesService.List", "/user/"+url.PathEscape(c.identity.Handle)+"/process", query, &resp)
+		} else {
+			err = getAPIJSON(ctx, c.svc, "OrgProcessesService.List", "/org/"+url.PathEscape(c.identity.Handle)+"/process", query, &resp)
+		}
+		return resp.GetItems(), resp.NextToken, err
+	}
+}
+
+func (c *identityClient) getProcess(ctx context.Context, processId string) (openapi.SpProcess, error) {
+	if c.identity.IsUser() {
+		resp, _, err := c.svc.UserProcesses.Get(ctx, c.identity.Handle, processId).Execute()
+		return resp, err
+	}
+	resp, _, err := c.svc.OrgProcesses.Get(ctx, c.identity.Handle, processId).Execute()
+	return resp, err
+}
+
+//// WORKSPACE RESOURCES
+
+func (c *identityClient) listWorkspaceAggregators(workspaceHandle string) listPageFunc[openapi.WorkspaceAggregator] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]openapi.WorkspaceAggregator, *string, error) {
+		var resp openapi.ListWorkspaceAggregatorsResponse
+		var err error
+		if c.identity.IsUser() {
+			req := c.svc.UserWorkspaceAggregators.List(ctx, c.identity.Handle, workspaceHandle).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		} else {
+			req := c.svc.OrgWorkspaceAggregators.List(ctx, c.identity.Handle, workspaceHandle).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		}
+		return resp.GetItems(), resp.NextToken, err
+	}
+}
+
+func (c *identityClient) getWorkspaceAggregator(ctx context.Context, workspaceHandle, aggregatorHandle string) (openapi.WorkspaceAggregator, error) {
+	if c.identity.IsUser() {
+		resp, _, err := c.svc.UserWorkspaceAggregators.Get(ctx, c.identity.Handle, workspaceHandle, aggregatorHandle).Execute()
+		return resp, err
+	}
+	resp, _, err := c.svc.OrgWorkspaceAggregators.Get(ctx, c.identity.Handle, workspaceHandle, aggregatorHandle).Execute()
+	return resp, err
+}
+
+func (c *identityClient) listWorkspaceConnectionAssociations(workspaceHandle string) listPageFunc[openapi.WorkspaceConn] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]openapi.WorkspaceConn, *string, error) {
+		var resp openapi.ListWorkspaceConnResponse
+		var err error
+		if c.identity.IsUser() {
+			req := c.svc.UserWorkspaceConnectionAssociations.List(ctx, c.identity.Handle, workspaceHandle).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		} else {
+			req := c.svc.OrgWorkspaceConnectionAssociations.List(ctx, c.identity.Handle, workspaceHandle).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		}
+		return resp.GetItems(), resp.NextToken, err
+	}
+}
+
+// listWorkspaceDBLogs lists the workspace's db logs matching filter. The
+// openapi client does not support the where parameter of the db log list, so
+// the request is made directly.
+func (c *identityClient) listWorkspaceDBLogs(workspaceHandle string, filter string) listPageFunc[openapi.LogRecord] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]openapi.LogRecord, *string, error) {
+		var resp openapi.ListLogsResponse
+		var err error
+		query := listQuery(nextToken, limit, filter)
+		if c.identity.IsUser() {
+			err = getAPIJSON(ctx, c.svc, "UserWorkspacesService.ListDBLogs", "/user/"+url.PathEscape(c.identity.Handle)+"/workspace/"+url.PathEscape(workspaceHandle)+"/db_log", query, &resp)
+		} else {
+			err = getAPIJSON(ctx, c.svc, "OrgWorkspacesService.ListDBLogs", "/org/"+url.PathEscape(c.identity.Handle)+"/workspace/"+url.PathEscape(workspaceHandle)+"/db_log", query, &resp)
+		}
+		return resp.GetItems(), resp.NextToken, err
+	}
+}
+
+func (c *identityClient) listWorkspaceMods(workspaceHandle string) listPageFunc[openapi.WorkspaceMod] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]openapi.WorkspaceMod, *string, error) {
+		var resp openapi.ListWorkspaceModsResponse
+		var err error
+		if c.identity.IsUser() {
+			req := c.svc.UserWorkspaceMods.List(ctx, c.identity.Handle, workspaceHandle).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		} else {
+			req := c.svc.OrgWorkspaceMods.List(ctx, c.identity.Handle, workspaceHandle).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		}
+		return resp.GetItems(), resp.NextToken, err
+	}
+}
+
+func (c *identityClient) getWorkspaceMod(ctx context.Context, workspaceHandle, modAlias string) (openapi.WorkspaceMod, error) {
+	if c.identity.IsUser() {
+		resp, _, err := c.svc.UserWorkspaceMods.Get(ctx, c.identity.Handle, workspaceHandle, modAlias).Execute()
+		return resp, err
+	}
+	resp, _, err := c.svc.OrgWorkspaceMods.Get(ctx, c.identity.Handle, workspaceHandle, modAlias).Execute()
+	return resp, err
+}
+
+func (c *identityClient) listWorkspaceModVariables(workspaceHandle, modAlias string) listPageFunc[openapi.WorkspaceModVariable] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]openapi.WorkspaceModVariable, *string, error) {
+		var resp openapi.ListWorkspaceModVariablesResponse
+		var err error
+		if c.identity.IsUser() {
+			req := c.svc.UserWorkspaceModVariables.List(ctx, c.identity.Handle, workspaceHandle, modAlias).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		} else {
+			req := c.svc.OrgWorkspaceModVariables.List(ctx, c.identity.Handle, workspaceHandle, modAlias).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		}
+		return resp.GetItems(), resp.NextToken, err
+	}
+}
+
+func (c *identityClient) listWorkspacePipelines(workspaceHandle, filter string) listPageFunc[openapi.Pipeline] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]openapi.Pipeline, *string, error) {
+		var resp openapi.ListPipelinesResponse
+		var err error
+		if c.identity.IsUser() {
+			req := c.svc.UserWorkspacePipelines.List(ctx, c.identity.Handle, workspaceHandle).Where(filter).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		} else {
+			req := c.svc.OrgWorkspacePipelines.List(ctx, c.identity.Handle, workspaceHandle).Where(filter).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		}
+		return resp.GetItems(), resp.NextToken, err
+	}
+}
+
+func (c *identityClient) getWorkspacePipeline(ctx context.Context, workspaceHandle, pipelineId string) (openapi.Pipeline, error) {
+	if c.identity.IsUser() {
+		resp, _, err := c.svc.UserWorkspacePipelines.Get(ctx, c.identity.Handle, workspaceHandle, pipelineId).Execute()
+		return resp, err
+	}
+	resp, _, err := c.svc.OrgWorkspacePipelines.Get(ctx, c.identity.Handle, workspaceHandle, pipelineId).Execute()
+	return resp, err
+}
+
+func (c *identityClient) listWorkspaceProcesses(workspaceHandle, filter string) listPageFunc[openapi.SpProcess] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]openapi.SpProcess, *string, error) {
+		var resp openapi.ListProcessesResponse
+		var err error
+		if c.identity.IsUser() {
+			req := c.svc.UserWorkspaceProcesses.List(ctx, c.identity.Handle, workspaceHandle).Where(filter).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		} else {
+			req := c.svc.OrgWorkspaceProcesses.List(ctx, c.identity.Handle, workspaceHandle).Where(filter).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		}
+		return resp.GetItems(), resp.NextToken, err
+	}
+}
+
+func (c *identityClient) getWorkspaceProcess(ctx context.Context, workspaceHandle, processId string) (openapi.SpProcess, error) {
+	if c.identity.IsUser() {
+		resp, _, err := c.svc.UserWorkspaceProcesses.Get(ctx, c.identity.Handle, workspaceHandle, processId).Execute()
+		return resp, err
+	}
+	resp, _, err := c.svc.OrgWorkspaceProcesses.Get(ctx, c.identity.Handle, workspaceHandle, processId).Execute()
+	return resp, err
+}
+
+// openWorkspaceProcessLog returns the response for the log of a workspace
+// process as JSON lines, for the caller to read and close. The openapi client
+// cannot decode JSON lines, so the request is made directly, which also
+// allows a long log to be streamed rather than read into memory.
+func (c *identityClient) openWorkspaceProcessLog(ctx context.Context, workspaceHandle, processId string) (*http.Response, error) {
+	path := "/workspace/" + url.PathEscape(workspaceHandle) + "/process/" + url.PathEscape(processId) + "/log/" + processLogFile + "." + processLogContentType
+	if c.identity.IsUser() {
+		return doAPIRequest(ctx, c.svc, http.MethodGet, "UserWorkspaceProcessesService.Log", "/user/"+url.PathEscape(c.identity.Handle)+path, nil, "application/jsonlines+json")
+	}
+	return doAPIRequest(ctx, c.svc, http.MethodGet, "OrgWorkspaceProcessesService.Log", "/org/"+url.PathEscape(c.identity.Handle)+path, nil, "application/jsonlines+json")
+}
+
+func (c *identityClient) listWorkspaceSnapshots(workspaceHandle, filter string) listPageFunc[openapi.WorkspaceSnapshot] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]openapi.WorkspaceSnapshot, *string, error) {
+		var resp openapi.ListWorkspaceSnapshotsResponse
+		var err error
+		if c.identity.IsUser() {
+			req := c.svc.UserWorkspaceSnapshots.List(ctx, c.identity.Handle, workspaceHandle).Where(filter).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		} else {
+			req := c.svc.OrgWorkspaceSnapshots.List(ctx, c.identity.Handle, workspaceHandle).Where(filter).Limit(limit)
+			if nextToken != nil {
+				req = req.NextToken(*nextToken)
+			}
+			resp, _, err = req.Execute()
+		}
+		return resp.GetItems(), resp.NextToken, err
+	}
+}
+
+func (c *identityClient) getWorkspaceSnapshot(ctx context.Context, workspaceHandle, snapshotId string) (openapi.WorkspaceSnapshot, error) {
+	if c.identity.IsUser() {
+		resp, _, err := c.svc.UserWorkspaceSnapshots.Get(ctx, c.identity.Handle, workspaceHandle, snapshotId).Execute()
+		return resp, err
+	}
+	resp, _, err := c.svc.OrgWorkspaceSnapshots.Get(ctx, c.identity.Handle, workspaceHandle, snapshotId).Execute()
+	return resp, err
+}
+
+// openWorkspaceSnapshotDownload requests the download of a snapshot in the
+// given format, e.g. json or csv, and returns the response for the caller to read and
+// close. The openapi client reads and decodes the whole snapshot into memory,
+// so the request is made directly to allow it to be streamed, or with a HEAD
+// request to find its size without downloading it.
+func (c *identityClient) openWorkspaceSnapshotDownload(ctx context.Context, method, workspaceHandle, snapshotId, contentType string) (*http.Response, error) {
+	path := "/workspace/" + url.PathEscape(workspaceHandle) + "/snapshot/" + url.PathEscape(snapshotId) + "." + url.PathEscape(contentType)
+	accept := snapshotFormatContentTypes[contentType]
+	if accept == "" {
+		accept = "application/json"
+	}
+	if c.identity.IsUser() {
+		return doAPIRequest(ctx, c.svc, method, "UserWorkspaceSnapshotsService.Download", "/user/"+url.PathEscape(c.identity.Handle)+path, nil, accept)
+	}
+	return doAPIRequest(ctx, c.svc, method, "OrgWorkspaceSnapshotsService.Download", "/org/"+url.PathEscape(c.identity.Handle)+path, nil, accept)
+}
@@ -0,0 +1,300 @@
+package steampipecloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+)
+
+const mockCloudToken = "spt_mock_token"
+
+// mockCloud is an in-memory fake of the subset of the Steampipe Cloud API used
+// by the plugin. Fixtures are registered against canonical paths which use
+// handles, e.g. "org/acme/workspace/dev/snapshot". Requests may address
+// identities by either handle or id, and workspace resources by either the
+// workspace handle or id, as the real API allows. A workspace itself is only
+// found by its handle.
+//
+// List endpoints page their results using pageSize, regardless of the limit
+// requested, so that multi-page responses can be exercised with few fixtures.
+type mockCloud struct {
+	t      *testing.T
+	server *httptest.Server
+	// connection config for queries, which points at the server
+	connectionConfig steampipecloudConfig
+
+	mu       sync.Mutex
+	pageSize int
+	// canonical path -> object returned for a GET on that path
+	objects map[string]interface{}
+	// canonical path -> items returned for a list on that path
+	lists map[string][]interface{}
+	// identity and workspace id -> handle
+	aliases map[string]string
+	// canonical path -> status codes to return, in order, before succeeding
+	failures map[string][]int
+	// Retry-After header value sent with 429 responses, if set
+	retryAfter string
+	// canonical paths of all requests received, in order
+	requests []string
+	// canonical path -> value of the where parameter on the last request
+	wheres map[string]string
+}
+
+func newMockCloud(t *testing.T) *mockCloud {
+	m := &mockCloud{
+		t:        t,
+		pageSize: 2,
+		objects:  map[string]interface{}{},
+		lists:    map[string][]interface{}{},
+		aliases:  map[string]string{},
+		failures: map[string][]int{},
+		wheres:   map[string]string{},
+	}
+	m.server = httptest.NewServer(http.HandlerFunc(m.serveHTTP))
+	t.Cleanup(m.server.Close)
+
+	host := m.server.URL
+	token := mockCloudToken
+	m.connectionConfig = steampipecloudConfig{Host: &host, Token: &token}
+	return m
+}
+
+// config returns the connection config used for queries, which points the
+// plugin at the mock server.
+func (m *mockCloud) config() steampipecloudConfig {
+	return m.connectionConfig
+}
+
+//// FIXTURES
+
+// setUser registers the authenticated user.
+func (m *mockCloud) setUser(user openapi.User) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.aliases[user.Id] = user.Handle
+	m.objects["actor"] = user
+	m.objects["user/"+user.Handle] = user
+	m.objects["identity/"+user.Handle] = openapi.Identity{Id: user.Id, Handle: user.Handle, Type: identityTypeUser}
+}
+
+// addOrg registers an org which the authenticated user is a member of.
+func (m *mockCloud) addOrg(org openapi.Org) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.aliases[org.Id] = org.Handle
+	m.objects["org/"+org.Handle] = org
+	m.objects["identity/"+org.Handle] = openapi.Identity{Id: org.Id, Handle: org.Handle, Type: identityTypeOrg}
+	m.lists["actor/org"] = append(m.lists["actor/org"], openapi.UserOrg{Org: &org, OrgId: org.Id})
+}
+
+// addWorkspace registers a workspace owned by the identity with the given
+// path prefix, e.g. "user/jane" or "org/acme".
+func (m *mockCloud) addWorkspace(owner string, workspace openapi.Workspace) {
+	m.add(owner+"/workspace", workspace)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, owner+"/workspace/"+workspace.Id)
+	m.aliases[workspace.Id] = workspace.Handle
+	ws := workspace
+	m.lists["actor/workspace"] = append(m.lists["actor/workspace"], openapi.ActorWorkspace{Workspace: &ws})
+}
+
+// add appends items to the list at path. Each item is also made available
+// for GET at path/<key> for each of its id, handle and alias fields.
+func (m *mockCloud) add(path string, items ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, item := range items {
+		m.lists[path] = append(m.lists[path], item)
+		for _, key := range mockObjectKeys(item) {
+			m.objects[path+"/"+key] = item
+		}
+	}
+}
+
+// set registers a single object returned for GET on path. A []byte object is
+// returned as-is rather than encoded as JSON.
+func (m *mockCloud) set(path string, object interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[path] = object
+}
+
+// fail makes the next requests for path return the given status codes.
+func (m *mockCloud) fail(path string, statusCodes ...int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures[path] = append(m.failures[path], statusCodes...)
+}
+
+// requestCount returns how many requests were made for path.
+func (m *mockCloud) requestCount(path string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := 0
+	for _, r := range m.requests {
+		if r == path {
+			count++
+		}
+	}
+	return count
+}
+
+// where returns the where parameter sent on the last request for path.
+func (m *mockCloud) where(path string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.wheres[path]
+}
+
+func mockObjectKeys(item interface{}) []string {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	var keys []string
+	for _, name := range []string{"id", "handle", "alias"} {
+		if v, ok := fields[name].(string); ok && v != "" {
+			keys = append(keys, v)
+		}
+	}
+	return keys
+}
+
+//// HANDLER
+
+func (m *mockCloud) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := m.canonicalPath(r.URL.Path)
+
+	// the version endpoint is public
+	if path != "version" && r.Header.Get("Authorization") != "Bearer "+mockCloudToken {
+		writeMockError(w, http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeMockError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	m.mu.Lock()
+	m.requests = append(m.requests, path)
+	m.wheres[path] = r.URL.Query().Get("where")
+	if failures := m.failures[path]; len(failures) > 0 {
+		m.failures[path] = failures[1:]
+		if failures[0] == http.StatusTooManyRequests && m.retryAfter != "" {
+			w.Header().Set("Retry-After", m.retryAfter)
+		}
+		m.mu.Unlock()
+		writeMockError(w, failures[0])
+		return
+	}
+	object, isObject := m.objects[path]
+	items, isList := m.lists[path]
+	pageSize := m.pageSize
+	m.mu.Unlock()
+
+	switch {
+	case isObject:
+		if raw, ok := object.([]byte); ok {
+			w.Header().Set("Content-Type", r.Header.Get("Accept"))
+			_, _ = w.Write(raw)
+			return
+		}
+		writeMockJSON(w, object)
+	case isList || mockIsListPath(path):
+		m.writeMockPage(w, r, items, pageSize)
+	default:
+		writeMockError(w, http.StatusNotFound)
+	}
+}
+
+// canonicalPath strips the API prefix from a request path and replaces
+// identity and workspace ids with their handles.
+func (m *mockCloud) canonicalPath(path string) string {
+	for _, prefix := range []string{"/api/v0", "/download"} {
+		path = strings.TrimPrefix(path, prefix)
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(segments) > 1 && (segments[0] == "user" || segments[0] == "org" || segments[0] == "identity") {
+		if handle, ok := m.aliases[segments[1]]; ok {
+			segments[1] = handle
+		}
+		if len(segments) > 4 && segments[2] == "workspace" {
+			if handle, ok := m.aliases[segments[3]]; ok {
+				segments[3] = handle
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// mockIsListPath returns true for collection paths, which return an empty
+// page rather than a 404 when no fixtures have been registered.
+func mockIsListPath(path string) bool {
+	segments := strings.Split(path, "/")
+	if len(segments) == 0 {
+		return false
+	}
+	switch segments[len(segments)-1] {
+	case "workspace", "connection", "conn", "process", "audit_log", "db_log", "snapshot", "pipeline",
+		"aggregator", "mod", "variable", "member", "token", "email", "org":
+		return true
+	}
+	return false
+}
+
+func (m *mockCloud) writeMockPage(w http.ResponseWriter, r *http.Request, items []interface{}, pageSize int) {
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 && limit < pageSize {
+		pageSize = limit
+	}
+
+	start := 0
+	if token := r.URL.Query().Get("next_token"); token != "" {
+		var err error
+		if start, err = strconv.Atoi(token); err != nil || start < 0 || start > len(items) {
+			writeMockError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	end := start + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+
+	page := map[string]interface{}{"items": items[start:end]}
+	if end < len(items) {
+		page["next_token"] = strconv.Itoa(end)
+	}
+	writeMockJSON(w, page)
+}
+
+func writeMockJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeMockError(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(openapi.ErrorModel{
+		Status: int32(status),
+		Title:  http.StatusText(status),
+		Detail: openapi.PtrString(fmt.Sprintf("mock %d", status)),
+	})
+}
@@ -0,0 +1,84 @@
+package steampipecloud
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+// defaultMaxResults is the page size requested from the API when the query
+// has no limit, or a limit greater than a single page.
+const defaultMaxResults = int32(100)
+
+// listPageFunc fetches a single page of results. nextToken is nil for the first
+// page. It returns the items on the page along with the token for the next page,
+// which is nil once the last page has been reached.
+type listPageFunc[T any] func(ctx context.Context, nextToken *string, limit int32) ([]T, *string, error)
+
+// listPage is the result of a single listPageFunc call, wrapped so that it can
+// be passed through plugin.RetryHydrate.
+type listPage[T any] struct {
+	Items     []T
+	NextToken *string
+}
+
+// listMaxResults returns the page size to request from the API.
+// If the requested number of items is less than the paging max limit
+// the limit is used instead.
+func listMaxResults(d *plugin.QueryData) int32 {
+	maxResults := defaultMaxResults
+	limit := d.QueryContext.Limit
+	if limit != nil && *limit < int64(maxResults) {
+		if *limit < 1 {
+			maxResults = int32(1)
+		} else {
+			maxResults = int32(*limit)
+		}
+	}
+	return maxResults
+}
+
+// paginate calls listPage until all pages have been read, streaming every item
+// returned. Each page request is retried using shouldRetryError, and paging
+// stops as soon as the query limit has been hit or the context is cancelled.
+func paginate[T any](ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, listPageFn listPageFunc[T]) error {
+	return forEachItem(ctx, d, h, listMaxResults(d), listPageFn, func(item T) bool {
+		d.StreamListItem(ctx, item)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		return d.RowsRemaining(ctx) != 0
+	})
+}
+
+// forEachItem calls listPage until all pages have been read, passing every
+// item returned to fn, and stops early if fn returns false. Each page request
+// is retried using shouldRetryError.
+func forEachItem[T any](ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, pageSize int32, listPageFn listPageFunc[T], fn func(item T) bool) error {
+	var nextToken *string
+	for {
+		pageToken := nextToken
+		listDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+			items, next, err := listPageFn(ctx, pageToken, pageSize)
+			return listPage[T]{Items: items, NextToken: next}, err
+		}
+
+		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
+		if err != nil {
+			return err
+		}
+
+		page := response.(listPage[T])
+		for _, item := range page.Items {
+			if !fn(item) {
+				return nil
+			}
+		}
+
+		// Stop if there are no more pages, or if the API hands back the token
+		// we just used, which would otherwise loop forever
+		if page.NextToken == nil || *page.NextToken == "" || (pageToken != nil && *page.NextToken == *pageToken) {
+			return nil
+		}
+		nextToken = page.NextToken
+	}
+}
@@ -0,0 +1,355 @@
+package steampipecloud
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	frequencyTypeCron     = "cron"
+	frequencyTypeInterval = "interval"
+
+	// cronSearchDays is how far a cron schedule is searched for its next or
+	// previous run, which covers schedules such as Feb 29 on a Monday.
+	cronSearchDays = 366 * 28
+)
+
+// pipelineIntervals maps the schedules of interval pipelines to their
+// equivalent cron expression and period.
+var pipelineIntervals = map[string]struct {
+	cron   string
+	months int
+	period time.Duration
+}{
+	"hourly":  {cron: "0 * * * *", period: time.Hour},
+	"daily":   {cron: "0 0 * * *", period: 24 * time.Hour},
+	"weekly":  {cron: "0 0 * * 0", period: 7 * 24 * time.Hour},
+	"monthly": {cron: "0 0 1 * *", months: 1},
+}
+
+// PipelineSchedule is the decoded frequency of a pipeline.
+type PipelineSchedule struct {
+	// FrequencyType is either cron or interval.
+	FrequencyType string `json:"frequency_type"`
+	// Schedule is the cron expression of a cron pipeline.
+	Schedule *string `json:"schedule"`
+	// Interval is the interval of an interval pipeline, e.g. daily.
+	Interval *string `json:"interval"`
+	// NextRunAt is the time the pipeline is next due to run.
+	NextRunAt *time.Time `json:"next_run_at"`
+	// PreviousRunAt is the time the pipeline was last due to run.
+	PreviousRunAt *time.Time `json:"previous_run_at"`
+}
+
+// decodePipelineSchedule decodes the frequency of a pipeline, and works out
+// when it was last and is next due to run, as of now. Cron schedules are in
+// UTC. Interval pipelines run at their interval from the run time reported by
+// the API, or from their creation time if it is not reported. A schedule
+// which cannot be parsed is returned with an error and no run times.
+func decodePipelineSchedule(frequencyType string, schedule *string, nextRunAt *string, createdAt string, now time.Time) (*PipelineSchedule, error) {
+	result := &PipelineSchedule{FrequencyType: frequencyType}
+	now = now.UTC()
+
+	var apiNextRunAt *time.Time
+	if nextRunAt != nil {
+		if t, err := time.Parse(time.RFC3339, *nextRunAt); err == nil {
+			apiNextRunAt = &t
+		}
+	}
+
+	switch frequencyType {
+	case frequencyTypeCron:
+		if schedule == nil {
+			return result, nil
+		}
+		result.Schedule = schedule
+
+		cron, err := parseCronSchedule(*schedule)
+		if err != nil {
+			return result, err
+		}
+		result.NextRunAt = cron.next(now)
+		result.PreviousRunAt = cron.prev(now)
+
+	case frequencyTypeInterval:
+		if schedule == nil {
+			return result, nil
+		}
+		result.Interval = schedule
+
+		interval, ok := pipelineIntervals[*schedule]
+		if !ok {
+			return result, fmt.Errorf("unknown pipeline interval %q", *schedule)
+		}
+		step := func(t time.Time, n int) time.Time {
+			if interval.months > 0 {
+				return t.AddDate(0, n*interval.months, 0)
+			}
+			return t.Add(time.Duration(n) * interval.period)
+		}
+
+		anchor := apiNextRunAt
+		if anchor == nil {
+			if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
+				anchor = &t
+			}
+		}
+		if anchor == nil {
+			return result, nil
+		}
+
+		// Jump from the anchor to the last run at or before now. The number of
+		// periods is estimated for monthly intervals, as months vary in length,
+		// and then corrected by a step either way.
+		start := anchor.UTC()
+		n := intervalsBetween(start, now, interval.months, interval.period)
+		previous := step(start, n)
+		for previous.After(now) {
+			n--
+			previous = step(start, n)
+		}
+		for !step(start, n+1).After(now) {
+			n++
+			previous = step(start, n)
+		}
+		next := step(start, n+1)
+		result.PreviousRunAt = &previous
+		result.NextRunAt = &next
+
+	default:
+		return result, nil
+	}
+
+	// The API knows best when the pipeline will next run
+	if apiNextRunAt != nil {
+		result.NextRunAt = apiNextRunAt
+	}
+
+	return result, nil
+}
+
+// intervalsBetween returns the number of whole intervals of the given months
+// or period from start to t, rounded down, which is negative if t is before
+// start.
+func intervalsBetween(start, t time.Time, months int, period time.Duration) int {
+	if months > 0 {
+		elapsed := (t.Year()-start.Year())*12 + int(t.Month()-start.Month())
+		n := elapsed / months
+		if elapsed < 0 && elapsed%months != 0 {
+			n--
+		}
+		return n
+	}
+	elapsed := t.Sub(start)
+	n := elapsed / period
+	if elapsed < 0 && elapsed%period != 0 {
+		n--
+	}
+	return int(n)
+}
+
+// cronSchedule is a parsed five field cron expression.
+type cronSchedule struct {
+	minutes, hours, daysOfMonth, months, daysOfWeek map[int]bool
+	// the times of day which match, in order
+	times []time.Duration
+	// cron matches a day if either the day of month or the day of week
+	// matches, unless one of them starts with *, e.g. * or */2, in which case
+	// both must match
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronDayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// parseCronSchedule parses a cron expression with minute, hour, day of month,
+// month and day of week fields, or one of the @ macros such as @daily.
+func parseCronSchedule(expression string) (*cronSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	if macro, ok := cronMacros[strings.ToLower(expression)]; ok {
+		expression = macro
+	}
+	// interval pipelines may use their interval name as the schedule
+	if interval, ok := pipelineIntervals[strings.ToLower(expression)]; ok {
+		expression = interval.cron
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields", expression)
+	}
+
+	var err error
+	s := &cronSchedule{}
+	if s.minutes, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: minute: %v", expression, err)
+	}
+	if s.hours, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: hour: %v", expression, err)
+	}
+	if s.daysOfMonth, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: day of month: %v", expression, err)
+	}
+	if s.months, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: month: %v", expression, err)
+	}
+	if s.daysOfWeek, err = parseCronField(fields[4], 0, 7, cronDayNames); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: day of week: %v", expression, err)
+	}
+	// 7 is also Sunday
+	if s.daysOfWeek[7] {
+		s.daysOfWeek[0] = true
+	}
+	s.anyDayOfMonth = strings.HasPrefix(fields[2], "*") || fields[2] == "?"
+	s.anyDayOfWeek = strings.HasPrefix(fields[4], "*") || fields[4] == "?"
+
+	for hour := 0; hour < 24; hour++ {
+		for minute := 0; minute < 60; minute++ {
+			if s.hours[hour] && s.minutes[minute] {
+				s.times = append(s.times, time.Duration(hour)*time.Hour+time.Duration(minute)*time.Minute)
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps,
+// e.g. "*/15", "1-5" or "mon,wed,fri".
+func parseCronField(field string, min, max int, names map[string]int) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangePart = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+		}
+
+		start, end := min, max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = parseCronValue(bounds[0], names); err != nil {
+				return nil, err
+			}
+			if end, err = parseCronValue(bounds[1], names); err != nil {
+				return nil, err
+			}
+		default:
+			value, err := parseCronValue(rangePart, names)
+			if err != nil {
+				return nil, err
+			}
+			start = value
+			// a single value with a step, e.g. 5/15, runs to the maximum
+			if step == 1 {
+				end = value
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("%q is out of the range %d-%d", part, min, max)
+		}
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+func parseCronValue(value string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	return v, nil
+}
+
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	if !s.months[int(t.Month())] {
+		return false
+	}
+	dayOfMonth := s.daysOfMonth[t.Day()]
+	dayOfWeek := s.daysOfWeek[int(t.Weekday())]
+	if s.anyDayOfMonth || s.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// next returns the first time after t which matches the schedule. Months
+// which do not match are skipped whole, so that rare schedules such as Feb 29
+// are found without checking every day.
+func (s *cronSchedule) next(t time.Time) *time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	end := day.AddDate(0, 0, cronSearchDays)
+
+	for day.Before(end) {
+		if !s.months[int(day.Month())] {
+			// the first day of the next month
+			day = time.Date(day.Year(), day.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.matchesDay(day) {
+			for _, offset := range s.times {
+				if candidate := day.Add(offset); !candidate.Before(t) {
+					return &candidate
+				}
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return nil
+}
+
+// prev returns the last time at or before t which matches the schedule.
+func (s *cronSchedule) prev(t time.Time) *time.Time {
+	t = t.UTC().Truncate(time.Minute)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	end := day.AddDate(0, 0, -cronSearchDays)
+
+	for day.After(end) {
+		if !s.months[int(day.Month())] {
+			// the last day of the previous month
+			day = time.Date(day.Year(), day.Month(), 0, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.matchesDay(day) {
+			for i := len(s.times) - 1; i >= 0; i-- {
+				if candidate := day.Add(s.times[i]); !candidate.After(t) {
+					return &candidate
+				}
+			}
+		}
+		day = day.AddDate(0, 0, -1)
+	}
+	return nil
+}
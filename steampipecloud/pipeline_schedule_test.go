@@ -0,0 +1,145 @@
+package steampipecloud
+
+import (
+	"testing"
+	"time"
+
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+)
+
+func TestDecodePipelineSchedule(t *testing.T) {
+	now := time.Date(2024, 3, 13, 10, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name          string
+		frequencyType string
+		schedule      *string
+		nextRunAt     *string
+		createdAt     string
+		previous      string
+		next          string
+	}{
+		{
+			name:          "weekdays",
+			frequencyType: "cron",
+			schedule:      openapi.PtrString("15 9 * * mon-fri"),
+			previous:      "2024-03-13T09:15:00Z",
+			next:          "2024-03-14T09:15:00Z",
+		},
+		{
+			name:          "steps",
+			frequencyType: "cron",
+			schedule:      openapi.PtrString("*/20 */6 * * *"),
+			previous:      "2024-03-13T06:40:00Z",
+			next:          "2024-03-13T12:00:00Z",
+		},
+		{
+			name:          "day of month or day of week",
+			frequencyType: "cron",
+			schedule:      openapi.PtrString("0 0 1 * sun"),
+			previous:      "2024-03-10T00:00:00Z",
+			next:          "2024-03-17T00:00:00Z",
+		},
+		{
+			name:          "day of month step and day of week",
+			frequencyType: "cron",
+			schedule:      openapi.PtrString("0 0 */2 * 1"),
+			previous:      "2024-03-11T00:00:00Z",
+			next:          "2024-03-25T00:00:00Z",
+		},
+		{
+			name:          "leap day",
+			frequencyType: "cron",
+			schedule:      openapi.PtrString("0 0 29 feb *"),
+			previous:      "2024-02-29T00:00:00Z",
+			next:          "2028-02-29T00:00:00Z",
+		},
+		{
+			name:          "macro",
+			frequencyType: "cron",
+			schedule:      openapi.PtrString("@monthly"),
+			previous:      "2024-03-01T00:00:00Z",
+			next:          "2024-04-01T00:00:00Z",
+		},
+		{
+			name:          "interval from creation",
+			frequencyType: "interval",
+			schedule:      openapi.PtrString("daily"),
+			createdAt:     "2024-01-02T12:00:00Z",
+			previous:      "2024-03-12T12:00:00Z",
+			next:          "2024-03-13T12:00:00Z",
+		},
+		{
+			name:          "interval from api next run",
+			frequencyType: "interval",
+			schedule:      openapi.PtrString("hourly"),
+			nextRunAt:     openapi.PtrString("2024-03-13T11:05:00Z"),
+			createdAt:     "2024-01-02T12:00:00Z",
+			previous:      "2024-03-13T10:05:00Z",
+			next:          "2024-03-13T11:05:00Z",
+		},
+		{
+			name:          "interval created long ago",
+			frequencyType: "interval",
+			schedule:      openapi.PtrString("hourly"),
+			createdAt:     "2004-06-01T08:20:00Z",
+			previous:      "2024-03-13T10:20:00Z",
+			next:          "2024-03-13T11:20:00Z",
+		},
+		{
+			name:          "monthly interval",
+			frequencyType: "interval",
+			schedule:      openapi.PtrString("monthly"),
+			createdAt:     "2023-11-15T00:00:00Z",
+			previous:      "2024-02-15T00:00:00Z",
+			next:          "2024-03-15T00:00:00Z",
+		},
+		{
+			name:          "interval created after now",
+			frequencyType: "interval",
+			schedule:      openapi.PtrString("daily"),
+			createdAt:     "2024-03-20T12:00:00Z",
+			previous:      "2024-03-12T12:00:00Z",
+			next:          "2024-03-13T12:00:00Z",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			schedule, err := decodePipelineSchedule(c.frequencyType, c.schedule, c.nextRunAt, c.createdAt, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if schedule.PreviousRunAt == nil || schedule.PreviousRunAt.Format(time.RFC3339) != c.previous {
+				t.Errorf("previous run: got %v, want %s", schedule.PreviousRunAt, c.previous)
+			}
+			if schedule.NextRunAt == nil || schedule.NextRunAt.Format(time.RFC3339) != c.next {
+				t.Errorf("next run: got %v, want %s", schedule.NextRunAt, c.next)
+			}
+		})
+	}
+}
+
+func TestDecodePipelineScheduleNeverRuns(t *testing.T) {
+	// Feb 30 never comes, so the whole search range is checked
+	schedule, err := decodePipelineSchedule("cron", openapi.PtrString("0 0 30 2 *"), nil, "", time.Date(2024, 3, 13, 10, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schedule.PreviousRunAt != nil || schedule.NextRunAt != nil {
+		t.Errorf("expected no runs, got %v and %v", schedule.PreviousRunAt, schedule.NextRunAt)
+	}
+}
+
+func TestDecodePipelineScheduleInvalid(t *testing.T) {
+	for _, expression := range []string{"* * *", "60 * * * *", "0 0 * * funday", "*/0 * * * *"} {
+		schedule, err := decodePipelineSchedule("cron", &expression, nil, "", time.Now())
+		if err == nil {
+			t.Errorf("%q: expected an error", expression)
+			continue
+		}
+		if schedule == nil || schedule.Schedule == nil || schedule.NextRunAt != nil {
+			t.Errorf("%q: expected the schedule without run times, got %+v", expression, schedule)
+		}
+	}
+}
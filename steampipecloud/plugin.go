@@ -15,32 +15,41 @@ func Plugin(ctx context.Context) *plugin.Plugin {
 		Name:             pluginName,
 		DefaultTransform: transform.FromGo(),
 		DefaultIgnoreConfig: &plugin.IgnoreConfig{
-			ShouldIgnoreErrorFunc: shouldIgnoreErrors([]string{"404"}),
+			ShouldIgnoreErrorFunc: shouldIgnoreErrors(apiErrorNotFound),
 		},
 		ConnectionConfigSchema: &plugin.ConnectionConfigSchema{
 			NewInstance: ConfigInstance,
 			Schema:      ConfigSchema,
 		},
 		TableMap: map[string]*plugin.Table{
-			"steampipecloud_audit_log":                     tableSteampipeCloudAuditLog(ctx),
-			"steampipecloud_connection":                    tableSteampipeCloudConnection(ctx),
-			"steampipecloud_organization_member":           tableSteampipeCloudOrganizationMember(ctx),
-			"steampipecloud_organization":                  tableSteampipeCloudOrganization(ctx),
-			"steampipecloud_process":                       tableSteampipeCloudProcess(ctx),
-			"steampipecloud_organization_workspace_member": tableSteampipeCloudOrganizationWorkspaceMember(ctx),
-			"steampipecloud_token":                         tableSteampipeCloudToken(ctx),
-			"steampipecloud_user":                          tableSteampipeCloudUser(ctx),
-			"steampipecloud_user_email":                    tableSteampipeCloudUserEmail(ctx),
-			"steampipecloud_user_preferences":              tableSteampipeCloudUserPreferences(ctx),
-			"steampipecloud_workspace":                     tableSteampipeCloudWorkspace(ctx),
-			"steampipecloud_workspace_aggregator":          tableSteampipeCloudWorkspaceAggregator(ctx),
-			"steampipecloud_workspace_connection":          tableSteampipeCloudWorkspaceConnection(ctx),
-			"steampipecloud_workspace_mod":                 tableSteampipeCloudWorkspaceMod(ctx),
-			"steampipecloud_workspace_mod_variable":        tableSteampipeCloudWorkspaceModVariable(ctx),
-			"steampipecloud_workspace_db_log":              tableSteampipeCloudWorkspaceDBLog(ctx),
-			"steampipecloud_workspace_pipeline":            tableSteampipeCloudWorkspacePipeline(ctx),
-			"steampipecloud_workspace_process":             tableSteampipeCloudWorkspaceProcess(ctx),
-			"steampipecloud_workspace_snapshot":            tableSteampipeCloudWorkspaceSnapshot(ctx),
+			"steampipecloud_audit_log":                         tableSteampipeCloudAuditLog(ctx),
+			"steampipecloud_connection":                        tableSteampipeCloudConnection(ctx),
+			"steampipecloud_invite":                            tableSteampipeCloudInvite(ctx),
+			"steampipecloud_organization_member":               tableSteampipeCloudOrganizationMember(ctx),
+			"steampipecloud_organization":                      tableSteampipeCloudOrganization(ctx),
+			"steampipecloud_process":                           tableSteampipeCloudProcess(ctx),
+			"steampipecloud_organization_workspace_member":     tableSteampipeCloudOrganizationWorkspaceMember(ctx),
+			"steampipecloud_token":                             tableSteampipeCloudToken(ctx),
+			"steampipecloud_user":                              tableSteampipeCloudUser(ctx),
+			"steampipecloud_user_email":                        tableSteampipeCloudUserEmail(ctx),
+			"steampipecloud_user_preferences":                  tableSteampipeCloudUserPreferences(ctx),
+			"steampipecloud_workspace":                         tableSteampipeCloudWorkspace(ctx),
+			"steampipecloud_workspace_access":                  tableSteampipeCloudWorkspaceAccess(ctx),
+			"steampipecloud_workspace_aggregator":              tableSteampipeCloudWorkspaceAggregator(ctx),
+			"steampipecloud_workspace_connection":              tableSteampipeCloudWorkspaceConnection(ctx),
+			"steampipecloud_workspace_mod":                     tableSteampipeCloudWorkspaceMod(ctx),
+			"steampipecloud_workspace_mod_variable":            tableSteampipeCloudWorkspaceModVariable(ctx),
+			"steampipecloud_workspace_db_log":                  tableSteampipeCloudWorkspaceDBLog(ctx),
+			"steampipecloud_workspace_db_log_summary":          tableSteampipeCloudWorkspaceDBLogSummary(ctx),
+			"steampipecloud_workspace_pipeline":                tableSteampipeCloudWorkspacePipeline(ctx),
+			"steampipecloud_workspace_pipeline_health":         tableSteampipeCloudWorkspacePipelineHealth(ctx),
+			"steampipecloud_workspace_process":                 tableSteampipeCloudWorkspaceProcess(ctx),
+			"steampipecloud_workspace_process_log":             tableSteampipeCloudWorkspaceProcessLog(ctx),
+			"steampipecloud_workspace_snapshot":                tableSteampipeCloudWorkspaceSnapshot(ctx),
+			"steampipecloud_workspace_snapshot_control_result": tableSteampipeCloudWorkspaceSnapshotControlResult(ctx),
+			"steampipecloud_workspace_snapshot_diff":           tableSteampipeCloudWorkspaceSnapshotDiff(ctx),
+			"steampipecloud_workspace_snapshot_panel":          tableSteampipeCloudWorkspaceSnapshotPanel(ctx),
+			"steampipecloud_workspace_snapshot_panel_row":      tableSteampipeCloudWorkspaceSnapshotPanelRow(ctx),
 		},
 	}
 
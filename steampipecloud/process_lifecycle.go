@@ -0,0 +1,127 @@
+package steampipecloud
+
+import (
+	"context"
+	"time"
+
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+// The outcomes of a process which has reached a terminal state.
+const (
+	processOutcomeSuccess   = "success"
+	processOutcomeFailure   = "failure"
+	processOutcomeCancelled = "cancelled"
+)
+
+// processStateOutcomes maps the terminal states of a process to their outcome.
+// Any other state, e.g. pending or running, is not terminal.
+var processStateOutcomes = map[string]string{
+	"finished":  processOutcomeSuccess,
+	"completed": processOutcomeSuccess,
+	"failed":    processOutcomeFailure,
+	"error":     processOutcomeFailure,
+	"cancelled": processOutcomeCancelled,
+	"canceled":  processOutcomeCancelled,
+}
+
+// processQueuedStates are the states of a process which has not yet started.
+var processQueuedStates = map[string]bool{
+	"pending": true,
+	"queued":  true,
+}
+
+// ProcessLifecycle is when a process started and finished, and how it ended.
+type ProcessLifecycle struct {
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	// Duration is in seconds, and runs until now for a process which is
+	// still running. It is nil for a terminal process whose finish time is
+	// not known.
+	Duration   *float64 `json:"duration"`
+	IsTerminal bool     `json:"is_terminal"`
+	Outcome    *string  `json:"outcome"`
+}
+
+// getProcessLifecycle works out the lifecycle of a process from its state and
+// timestamps, for both the identity and workspace process tables.
+func getProcessLifecycle(_ context.Context, _ *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	return newProcessLifecycle(h.Item.(openapi.SpProcess), time.Now()), nil
+}
+
+func newProcessLifecycle(process openapi.SpProcess, now time.Time) *ProcessLifecycle {
+	lifecycle := &ProcessLifecycle{
+		StartedAt:  processStartedAt(process),
+		FinishedAt: processFinishedAt(process),
+	}
+	if outcome := processOutcome(process); outcome != "" {
+		lifecycle.IsTerminal = true
+		lifecycle.Outcome = &outcome
+	}
+
+	switch {
+	case lifecycle.FinishedAt != nil:
+		if duration := processDuration(process); duration != nil {
+			seconds := duration.Seconds()
+			lifecycle.Duration = &seconds
+		}
+	case lifecycle.StartedAt != nil && !lifecycle.IsTerminal:
+		seconds := now.Sub(*lifecycle.StartedAt).Seconds()
+		lifecycle.Duration = &seconds
+	}
+
+	return lifecycle
+}
+
+// processStartedAt returns the time a process started, or nil if it is still
+// queued. The API does not record when a process leaves the queue, so this is
+// the time it was created.
+func processStartedAt(process openapi.SpProcess) *time.Time {
+	if process.State == nil || processQueuedStates[*process.State] {
+		return nil
+	}
+	createdAt, err := time.Parse(time.RFC3339, process.CreatedAt)
+	if err != nil {
+		return nil
+	}
+	return &createdAt
+}
+
+// processOutcome returns the outcome of a process, or "" if it has not
+// reached a terminal state.
+func processOutcome(process openapi.SpProcess) string {
+	if process.State == nil {
+		return ""
+	}
+	return processStateOutcomes[*process.State]
+}
+
+// processFinishedAt returns the time a terminal process finished, which is
+// the time it was last updated, or nil if it has not finished.
+func processFinishedAt(process openapi.SpProcess) *time.Time {
+	if processOutcome(process) == "" {
+		return nil
+	}
+	finishedAt, err := time.Parse(time.RFC3339, process.UpdatedAt)
+	if err != nil {
+		return nil
+	}
+	return &finishedAt
+}
+
+// processDuration returns how long a terminal process ran for, from its
+// creation until it finished, or nil if it has not finished.
+func processDuration(process openapi.SpProcess) *time.Duration {
+	finishedAt := processFinishedAt(process)
+	if finishedAt == nil {
+		return nil
+	}
+	createdAt, err := time.Parse(time.RFC3339, process.CreatedAt)
+	if err != nil {
+		return nil
+	}
+	duration := finishedAt.Sub(createdAt)
+	return &duration
+}
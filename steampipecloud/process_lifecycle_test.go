@@ -0,0 +1,39 @@
+package steampipecloud
+
+import (
+	"testing"
+	"time"
+
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+)
+
+func TestNewProcessLifecycleDuration(t *testing.T) {
+	now := time.Date(2024, 3, 13, 10, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name      string
+		state     string
+		updatedAt string
+		duration  *float64
+	}{
+		{name: "finished", state: "finished", updatedAt: "2024-03-13T10:01:30Z", duration: openapi.PtrFloat64(90)},
+		{name: "running", state: "running", updatedAt: "2024-03-13T10:01:30Z", duration: openapi.PtrFloat64(1800)},
+		{name: "queued", state: "pending", updatedAt: "2024-03-13T10:01:30Z"},
+		// a finished process without a finish time has no duration, rather
+		// than one running until now
+		{name: "finished at unknown time", state: "failed", updatedAt: "not a time"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			process := openapi.SpProcess{State: openapi.PtrString(c.state), CreatedAt: "2024-03-13T10:00:00Z", UpdatedAt: c.updatedAt}
+			lifecycle := newProcessLifecycle(process, now)
+			switch {
+			case c.duration == nil && lifecycle.Duration != nil:
+				t.Errorf("expected no duration, got %v", *lifecycle.Duration)
+			case c.duration != nil && (lifecycle.Duration == nil || *lifecycle.Duration != *c.duration):
+				t.Errorf("expected duration %v, got %v", *c.duration, lifecycle.Duration)
+			}
+		})
+	}
+}
@@ -0,0 +1,452 @@
+package steampipecloud
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// QueryShape describes the structure of a SQL query independently of its
+// literal values, so that queries which differ only by their literals can be
+// grouped together.
+type QueryShape struct {
+	// Fingerprint is a hash of the normalized query.
+	Fingerprint string `json:"fingerprint"`
+	// Normalized is the query with comments removed, whitespace collapsed,
+	// keywords and identifiers lower cased and literals replaced by "?".
+	Normalized string `json:"normalized"`
+	// TablesReferenced are the tables read or written by the query, as written
+	// in the query, e.g. aws_s3_bucket or aws.aws_s3_bucket.
+	TablesReferenced []string `json:"tables_referenced"`
+	// StatementType is the type of the top level statement, e.g. select.
+	StatementType string `json:"statement_type"`
+}
+
+// fingerprintLength is the number of hex characters of the hash kept in the
+// fingerprint.
+const fingerprintLength = 16
+
+type sqlTokenKind int
+
+const (
+	sqlWord sqlTokenKind = iota
+	sqlQuotedIdentifier
+	sqlLiteral
+	sqlPunctuation
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// tableKeywords are the keywords which are followed by a table name.
+var tableKeywords = map[string]bool{
+	"from":   true,
+	"join":   true,
+	"update": true,
+	"into":   true,
+}
+
+// subqueryKeywords are the keywords which start a statement inside
+// parentheses, e.g. a subquery or the body of a common table expression.
+var subqueryKeywords = map[string]bool{
+	"select": true,
+	"with":   true,
+	"values": true,
+	"table":  true,
+}
+
+// unaryKeywords are the keywords after which a + or - is a sign rather than
+// an operator, e.g. select -1 or where x between -1 and 1.
+var unaryKeywords = map[string]bool{
+	"select": true, "where": true, "and": true, "or": true, "not": true, "when": true, "then": true, "else": true,
+	"in": true, "between": true, "like": true, "ilike": true, "is": true, "case": true, "on": true, "having": true,
+	"set": true, "values": true, "limit": true, "offset": true, "by": true, "return": true, "returning": true,
+	"any": true, "all": true, "distinct": true, "as": true,
+}
+
+// statementKeywords are the statement types reported for the main statement
+// of a query which starts with a with clause.
+var statementKeywords = map[string]bool{
+	"select": true,
+	"insert": true,
+	"update": true,
+	"delete": true,
+	"merge":  true,
+}
+
+// analyzeQuery returns the shape of a SQL query. It uses a tokenizer rather
+// than a full parser, so the tables referenced are a best effort for complex
+// queries.
+func analyzeQuery(query string) QueryShape {
+	tokens := tokenizeSQL(query)
+	normalized := normalizeSQL(tokens)
+
+	hash := sha256.Sum256([]byte(normalized))
+	return QueryShape{
+		Fingerprint:      hex.EncodeToString(hash[:])[:fingerprintLength],
+		Normalized:       normalized,
+		TablesReferenced: sqlTablesReferenced(tokens),
+		StatementType:    sqlStatementType(tokens),
+	}
+}
+
+// tokenizeSQL splits a query into tokens, dropping comments and whitespace.
+// String, numeric, boolean and parameter literals become sqlLiteral tokens.
+func tokenizeSQL(query string) []sqlToken {
+	var tokens []sqlToken
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		// -- line comment
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+		// /* block comment */
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				i++
+			}
+			i += 2
+
+		// 'string', with '' escapes
+		case r == '\'':
+			i = skipQuoted(runes, i, '\'')
+			tokens = append(tokens, sqlToken{kind: sqlLiteral})
+
+		// E'string', with backslash escapes
+		case (r == 'e' || r == 'E') && i+1 < len(runes) && runes[i+1] == '\'':
+			i++
+			for i++; i < len(runes) && runes[i] != '\''; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+			i++
+			tokens = append(tokens, sqlToken{kind: sqlLiteral})
+
+		// "quoted identifier"
+		case r == '"':
+			start := i
+			i = skipQuoted(runes, i, '"')
+			tokens = append(tokens, sqlToken{kind: sqlQuotedIdentifier, text: string(runes[start:i])})
+
+		// $1 parameter, or $tag$ dollar quoted string
+		case r == '$':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			if j < len(runes) && runes[j] == '$' {
+				i = skipDollarQuoted(runes, runes[i:j+1], j+1)
+			} else {
+				i = j
+			}
+			tokens = append(tokens, sqlToken{kind: sqlLiteral})
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				((runes[i] == '+' || runes[i] == '-') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlLiteral})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$') {
+				i++
+			}
+			word := strings.ToLower(string(runes[start:i]))
+			if word == "true" || word == "false" {
+				tokens = append(tokens, sqlToken{kind: sqlLiteral})
+				continue
+			}
+			tokens = append(tokens, sqlToken{kind: sqlWord, text: word})
+
+		default:
+			// keep multi character operators such as ::, <>, >= and || together
+			start := i
+			i++
+			for i < len(runes) && strings.ContainsRune("<>=!|:&~", runes[i]) && strings.ContainsRune("<>=!|:&~", r) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlPunctuation, text: string(runes[start:i])})
+		}
+	}
+
+	return tokens
+}
+
+// skipQuoted returns the index after the quoted string or identifier starting
+// at i, where a doubled quote is an escaped quote.
+func skipQuoted(runes []rune, i int, quote rune) int {
+	for i++; i < len(runes); i++ {
+		if runes[i] == quote {
+			if i+1 < len(runes) && runes[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return i
+}
+
+// skipDollarQuoted returns the index after the closing tag of a dollar quoted
+// string whose body starts at i.
+func skipDollarQuoted(runes []rune, tag []rune, i int) int {
+	for ; i+len(tag) <= len(runes); i++ {
+		if string(runes[i:i+len(tag)]) == string(tag) {
+			return i + len(tag)
+		}
+	}
+	return len(runes)
+}
+
+// normalizeSQL renders tokens as a single line, replacing literals with "?"
+// and collapsing lists of literals, e.g. in (1, 2, 3), to a single "?".
+func normalizeSQL(tokens []sqlToken) string {
+	tokens = foldSignedLiterals(tokens)
+
+	var parts []sqlToken
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if token.kind == sqlLiteral {
+			token.text = "?"
+			// skip the rest of a list of literals
+			for i+2 < len(tokens) && tokens[i+1].text == "," && tokens[i+2].kind == sqlLiteral {
+				i += 2
+			}
+		}
+		parts = append(parts, token)
+	}
+
+	var sb strings.Builder
+	for i, part := range parts {
+		if i > 0 && !noSpaceBefore(part.text) && !noSpaceAfter(parts[i-1].text) && !isSQLCall(parts[i-1], part) {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(part.text)
+	}
+	return strings.TrimSuffix(sb.String(), ";")
+}
+
+// foldSignedLiterals drops the sign of signed numeric literals, e.g. = -1, so
+// that they normalize in the same way as unsigned ones. A + or - after an
+// operand, e.g. a - 1, is an operator and is kept.
+func foldSignedLiterals(tokens []sqlToken) []sqlToken {
+	var folded []sqlToken
+	for i, token := range tokens {
+		if token.kind == sqlPunctuation && (token.text == "-" || token.text == "+") &&
+			i+1 < len(tokens) && tokens[i+1].kind == sqlLiteral && (i == 0 || isSQLSignContext(tokens[i-1])) {
+			continue
+		}
+		folded = append(folded, token)
+	}
+	return folded
+}
+
+// isSQLSignContext returns true if a + or - following the token is a sign.
+func isSQLSignContext(previous sqlToken) bool {
+	switch previous.kind {
+	case sqlPunctuation:
+		return previous.text != ")"
+	case sqlWord:
+		return unaryKeywords[previous.text]
+	}
+	return false
+}
+
+func noSpaceBefore(part string) bool {
+	return part == "," || part == ")" || part == "." || part == ";" || part == "::"
+}
+
+// isSQLCall returns true if the tokens are a name followed by an opening
+// parenthesis, e.g. a function call or the column list of an insert.
+func isSQLCall(name sqlToken, next sqlToken) bool {
+	if next.text != "(" || !isSQLName(name) {
+		return false
+	}
+	switch name.text {
+	case "in", "as", "values", "and", "or", "not", "exists", "any", "all", "on", "using", "from", "join", "where",
+		"select", "over", "filter", "then", "else", "when", "case", "is", "union", "intersect", "except", "lateral":
+		return false
+	}
+	return true
+}
+
+func noSpaceAfter(part string) bool {
+	return part == "(" || part == "." || part == "::"
+}
+
+// sqlTablesReferenced returns the sorted, distinct names following from,
+// join, update and into, including each name of a comma separated from list.
+// Names of common table expressions, subqueries and set returning functions
+// are not included. From and join are only table keywords directly inside a
+// statement, not in expressions such as extract(year from created_at) or
+// x is distinct from y.
+func sqlTablesReferenced(tokens []sqlToken) []string {
+	statementLevel := sqlStatementLevel(tokens)
+
+	cteNames := map[string]bool{}
+	for i := 0; i+2 < len(tokens); i++ {
+		if (i == 0 || tokens[i-1].text == "with" || tokens[i-1].text == "," || tokens[i-1].text == "recursive") &&
+			isSQLName(tokens[i]) && tokens[i+1].text == "as" && tokens[i+2].text == "(" {
+			cteNames[tokens[i].text] = true
+		}
+	}
+
+	found := map[string]bool{}
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].kind != sqlWord || !tableKeywords[tokens[i].text] {
+			continue
+		}
+		keyword := tokens[i].text
+		if (keyword == "from" || keyword == "join") && !statementLevel[i] {
+			continue
+		}
+		if keyword == "from" && i > 0 && tokens[i-1].text == "distinct" {
+			continue
+		}
+		// a locking clause, e.g. for update of t or for no key update, names
+		// tables already in the from list
+		if keyword == "update" && i > 0 && (tokens[i-1].text == "for" || tokens[i-1].text == "key") {
+			continue
+		}
+		for j := i + 1; j < len(tokens); {
+			if tokens[j].text == "only" || tokens[j].text == "lateral" {
+				j++
+				continue
+			}
+			name, next := readSQLName(tokens, j)
+			if name == "" {
+				break
+			}
+			// a function call, e.g. from jsonb_array_elements(...), rather than
+			// the column list of an insert
+			if keyword != "into" && next < len(tokens) && tokens[next].text == "(" {
+				break
+			}
+			if !cteNames[name] {
+				found[name] = true
+			}
+			if keyword != "from" {
+				break
+			}
+			// skip an alias, then continue if the from list continues
+			j = next
+			if j < len(tokens) && tokens[j].text == "as" {
+				j++
+			}
+			if j < len(tokens) && isSQLName(tokens[j]) && !isSQLKeyword(tokens[j].text) {
+				j++
+			}
+			if j >= len(tokens) || tokens[j].text != "," {
+				break
+			}
+			j++
+		}
+	}
+
+	tables := make([]string, 0, len(found))
+	for name := range found {
+		tables = append(tables, name)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// sqlStatementLevel returns, for each token, whether it is directly inside a
+// statement, i.e. at the top level of the query or of a parenthesised
+// subquery, rather than inside an expression such as a function call.
+func sqlStatementLevel(tokens []sqlToken) []bool {
+	levels := make([]bool, len(tokens))
+	stack := []bool{true}
+	for i, token := range tokens {
+		levels[i] = stack[len(stack)-1]
+		if token.kind != sqlPunctuation {
+			continue
+		}
+		switch token.text {
+		case "(":
+			stack = append(stack, i+1 < len(tokens) && tokens[i+1].kind == sqlWord && subqueryKeywords[tokens[i+1].text])
+		case ")":
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return levels
+}
+
+// readSQLName reads a possibly qualified name starting at tokens[i], returning
+// it and the index of the token after it.
+func readSQLName(tokens []sqlToken, i int) (string, int) {
+	if i >= len(tokens) || !isSQLName(tokens[i]) || isSQLKeyword(tokens[i].text) {
+		return "", i
+	}
+	name := tokens[i].text
+	i++
+	for i+1 < len(tokens) && tokens[i].text == "." && isSQLName(tokens[i+1]) {
+		name += "." + tokens[i+1].text
+		i += 2
+	}
+	return name, i
+}
+
+func isSQLName(token sqlToken) bool {
+	return token.kind == sqlWord || token.kind == sqlQuotedIdentifier
+}
+
+// isSQLKeyword returns true for the reserved words which can follow a table
+// name, and so cannot be an alias.
+func isSQLKeyword(word string) bool {
+	switch word {
+	case "select", "where", "group", "order", "having", "limit", "offset", "join", "inner", "left", "right", "full",
+		"cross", "natural", "on", "using", "union", "intersect", "except", "set", "values", "returning", "window",
+		"fetch", "for", "default", "as", "with":
+		return true
+	}
+	return false
+}
+
+// sqlStatementType returns the first keyword of the query, or for a query
+// with a with clause, the first top level statement keyword after it.
+func sqlStatementType(tokens []sqlToken) string {
+	i := 0
+	for i < len(tokens) && tokens[i].text == "(" {
+		i++
+	}
+	if i >= len(tokens) || tokens[i].kind != sqlWord {
+		return ""
+	}
+	if tokens[i].text != "with" {
+		return tokens[i].text
+	}
+
+	depth := 0
+	for _, token := range tokens[i+1:] {
+		switch token.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		default:
+			if depth == 0 && token.kind == sqlWord && statementKeywords[token.text] {
+				return token.text
+			}
+		}
+	}
+	return "with"
+}
@@ -0,0 +1,95 @@
+package steampipecloud
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeQuery(t *testing.T) {
+	cases := []struct {
+		query         string
+		normalized    string
+		tables        []string
+		statementType string
+	}{
+		{
+			query:         "SELECT name, region\n  FROM aws_s3_bucket WHERE region = 'us-east-1' -- buckets\n  AND versioning_enabled = true;",
+			normalized:    "select name, region from aws_s3_bucket where region = ? and versioning_enabled = ?",
+			tables:        []string{"aws_s3_bucket"},
+			statementType: "select",
+		},
+		{
+			query:         "select * from aws.aws_ec2_instance i join aws.aws_vpc as v on i.vpc_id = v.vpc_id where i.instance_id in ('i-1', 'i-2', 'i-3') limit 10",
+			normalized:    "select * from aws.aws_ec2_instance i join aws.aws_vpc as v on i.vpc_id = v.vpc_id where i.instance_id in (?) limit ?",
+			tables:        []string{"aws.aws_ec2_instance", "aws.aws_vpc"},
+			statementType: "select",
+		},
+		{
+			query:         "with buckets as (select name from aws_s3_bucket) select b.name, t from buckets b, aws_s3_bucket_policy p, jsonb_array_elements(p.tags) t /* policies */ where b.name = $1",
+			normalized:    "with buckets as (select name from aws_s3_bucket) select b.name, t from buckets b, aws_s3_bucket_policy p, jsonb_array_elements(p.tags) t where b.name = ?",
+			tables:        []string{"aws_s3_bucket", "aws_s3_bucket_policy"},
+			statementType: "select",
+		},
+		{
+			query:         "insert into \"Audit\" (msg, n) values ($tag$it's$tag$, -1.5e3)",
+			normalized:    "insert into \"Audit\"(msg, n) values (?)",
+			tables:        []string{"\"Audit\""},
+			statementType: "insert",
+		},
+		{
+			query:         "select extract(year from created_at), trim(both ' ' from name), substring(arn from 5 for 3) from aws_s3_bucket where a is distinct from b",
+			normalized:    "select extract(year from created_at), trim(both ? from name), substring(arn from ? for ?) from aws_s3_bucket where a is distinct from b",
+			tables:        []string{"aws_s3_bucket"},
+			statementType: "select",
+		},
+		{
+			query:         "select overlay(name placing 'x' from 2) from aws_iam_user where arn in (select arn from aws_iam_role) and x - 1 > -2",
+			normalized:    "select overlay(name placing ? from ?) from aws_iam_user where arn in (select arn from aws_iam_role) and x - ? > ?",
+			tables:        []string{"aws_iam_role", "aws_iam_user"},
+			statementType: "select",
+		},
+		{
+			query:         "select * from accounts a join orders o on o.account_id = a.id where a.active = false for update of a, o",
+			normalized:    "select * from accounts a join orders o on o.account_id = a.id where a.active = ? for update of a, o",
+			tables:        []string{"accounts", "orders"},
+			statementType: "select",
+		},
+		{
+			query:         "select * from accounts for no key update of accounts skip locked",
+			normalized:    "select * from accounts for no key update of accounts skip locked",
+			tables:        []string{"accounts"},
+			statementType: "select",
+		},
+	}
+
+	for _, tc := range cases {
+		shape := analyzeQuery(tc.query)
+		if shape.Normalized != tc.normalized {
+			t.Errorf("%q: expected normalized %q, got %q", tc.query, tc.normalized, shape.Normalized)
+		}
+		if !reflect.DeepEqual(shape.TablesReferenced, tc.tables) {
+			t.Errorf("%q: expected tables %v, got %v", tc.query, tc.tables, shape.TablesReferenced)
+		}
+		if shape.StatementType != tc.statementType {
+			t.Errorf("%q: expected statement type %q, got %q", tc.query, tc.statementType, shape.StatementType)
+		}
+	}
+
+	// queries differing only by literals, case and whitespace share a fingerprint
+	first := analyzeQuery("select * from aws_s3_bucket where name = 'a'")
+	second := analyzeQuery("SELECT *\nFROM aws_s3_bucket\nWHERE name = 'b';")
+	if first.Fingerprint != second.Fingerprint || len(first.Fingerprint) != fingerprintLength {
+		t.Errorf("expected matching fingerprints, got %q and %q", first.Fingerprint, second.Fingerprint)
+	}
+	// a negative literal shares the fingerprint of a positive one
+	if negative, positive := analyzeQuery("select * from t where n = -1"), analyzeQuery("select * from t where n = 1"); negative.Fingerprint != positive.Fingerprint {
+		t.Errorf("expected matching fingerprints for signed literals, got %q and %q", negative.Normalized, positive.Normalized)
+	}
+	// boolean literals are folded like other literals
+	if truthy, falsy := analyzeQuery("select * from t where x = true"), analyzeQuery("select * from t where x = FALSE"); truthy.Fingerprint != falsy.Fingerprint {
+		t.Errorf("expected matching fingerprints for boolean literals, got %q and %q", truthy.Normalized, falsy.Normalized)
+	}
+	if other := analyzeQuery("select * from aws_s3_bucket where region = 'a'"); other.Fingerprint == first.Fingerprint {
+		t.Error("expected a different fingerprint for a different query")
+	}
+}
@@ -0,0 +1,171 @@
+package steampipecloud
+
+import (
+	"context"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRequestsPerSecond and defaultRequestsBurst are the rate limits used
+	// when they are not set in the connection config. Requests are not limited
+	// unless requests_per_second is set, as they were not before it existed.
+	defaultRequestsPerSecond = 0
+	defaultRequestsBurst     = 50
+
+	// A rate limited request is retried at most this many times by the
+	// transport, after waiting for the Retry-After duration. If the limit is
+	// still hit the response is returned, and the hydrate retry applies.
+	maxRetryAfterAttempts = 3
+	// Retry-After durations longer than this are not waited for by the transport.
+	maxRetryAfterDelay = 60 * time.Second
+)
+
+// rateLimiter is a token bucket which allows requests at a steady rate, with
+// bursts of up to burst requests.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(requestsPerSecond, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   float64(requestsPerSecond),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a request is allowed, or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	// take the token now, waiting for it to be refilled if the bucket is empty
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if err := sleepWithContext(ctx, delay); err != nil {
+		// return the unused token
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// rateLimitedTransport applies the connection's rate limit to every API
+// request, and retries rate limited requests after the delay requested by the
+// server's Retry-After header.
+type rateLimitedTransport struct {
+	transport http.RoundTripper
+	// limiter is nil if requests are not rate limited
+	limiter *rateLimiter
+	// onUnauthorized, if set, is called when a request is rejected with a
+	// 401 Unauthorized response
+	onUnauthorized func()
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		if t.limiter != nil {
+			if err := t.limiter.wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.transport.RoundTrip(req)
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && t.onUnauthorized != nil {
+			t.onUnauthorized()
+		}
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt > maxRetryAfterAttempts {
+			return resp, err
+		}
+
+		delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok || delay > maxRetryAfterDelay {
+			return resp, err
+		}
+		// the request body can only be sent again if it can be recreated
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, err
+		}
+
+		log.Printf("[WARN] Received Rate Limit Error, retrying after %s", delay)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if err := sleepWithContext(ctx, delay); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// parseRetryAfter returns the delay requested by a Retry-After header, which
+// is either a number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := date.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+func sleepWithContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
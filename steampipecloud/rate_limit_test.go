@@ -0,0 +1,69 @@
+package steampipecloud
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/quals"
+)
+
+func TestRateLimiterWaits(t *testing.T) {
+	limiter := newRateLimiter(20, 2)
+	ctx := context.Background()
+
+	start := time.Now()
+	// the first two requests use the burst, the next three wait 50ms each
+	for i := 0; i < 5; i++ {
+		if err := limiter.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("expected requests to be rate limited, took %s", elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, false},
+		{"Mon, 01 May 2023 12:00:05 GMT", 5 * time.Second, true},
+		{"Mon, 01 May 2023 11:59:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tc := range cases {
+		delay, ok := parseRetryAfter(tc.value, now)
+		if delay != tc.expected || ok != tc.ok {
+			t.Errorf("parseRetryAfter(%q) = %s, %v; expected %s, %v", tc.value, delay, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+func TestListHonorsRetryAfter(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.retryAfter = "1"
+	m.fail("org/acme/workspace", http.StatusTooManyRequests)
+
+	start := time.Now()
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace", quals: []*quals.Qual{equalsQual("identity_handle", "acme")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("expected 2 workspaces, got %d", len(rows))
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected the request to be retried after 1s, took %s", elapsed)
+	}
+	if count := m.requestCount("org/acme/workspace"); count != 2 {
+		t.Errorf("expected the rate limited request to be retried once, got %d requests", count)
+	}
+}
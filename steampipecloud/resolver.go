@@ -0,0 +1,76 @@
+package steampipecloud
+
+import (
+	"context"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultLookupTTL is how long memoized results are cached, which is the
+// default expiry of the connection cache.
+const defaultLookupTTL = time.Hour
+
+// lookupGroup deduplicates concurrent lookups of the same key.
+var lookupGroup singleflight.Group
+
+// memoizeLookup returns the cached result for cacheKey, calling lookup to
+// populate the cache if it is not found. The cache is scoped to the connection
+// and shared by all tables, so e.g. the identities of thousands of snapshots
+// are only looked up once each. Concurrent callers for the same key wait for
+// a single call to lookup rather than each calling the API.
+func memoizeLookup[T any](ctx context.Context, d *plugin.QueryData, cacheKey string, lookup func() (T, error)) (T, error) {
+	return memoizeLookupTTL(ctx, d, cacheKey, lookup, nil)
+}
+
+// memoizeLookupIf is memoizeLookup, but only caches results for which
+// cacheable returns true. Results which are not cached are still shared by
+// concurrent callers.
+func memoizeLookupIf[T any](ctx context.Context, d *plugin.QueryData, cacheKey string, lookup func() (T, error), cacheable func(T) bool) (T, error) {
+	return memoizeLookupTTL(ctx, d, cacheKey, lookup, func(value T) time.Duration {
+		if cacheable(value) {
+			return defaultLookupTTL
+		}
+		return 0
+	})
+}
+
+// memoizeLookupTTL is memoizeLookup, but caches each result for the duration
+// returned by ttl, or not at all if it returns 0. A nil ttl caches results
+// for defaultLookupTTL.
+func memoizeLookupTTL[T any](ctx context.Context, d *plugin.QueryData, cacheKey string, lookup func() (T, error), ttl func(T) time.Duration) (T, error) {
+	// if found in cache, return the result
+	if cachedData, ok := d.ConnectionManager.Cache.Get(cacheKey); ok {
+		return cachedData.(T), nil
+	}
+
+	result, err, _ := lookupGroup.Do(d.Connection.Name+"/"+cacheKey, func() (interface{}, error) {
+		// the result may have been cached by a lookup which completed while
+		// this one was waiting to start
+		if cachedData, ok := d.ConnectionManager.Cache.Get(cacheKey); ok {
+			return cachedData, nil
+		}
+
+		value, err := lookup()
+		if err != nil {
+			return nil, err
+		}
+
+		// save to extension cache
+		expiry := defaultLookupTTL
+		if ttl != nil {
+			expiry = ttl(value)
+		}
+		if expiry > 0 {
+			d.ConnectionManager.Cache.SetWithTTL(cacheKey, value, expiry)
+		}
+		return value, nil
+	})
+	if err != nil {
+		var empty T
+		return empty, err
+	}
+
+	return result.(T), nil
+}
@@ -0,0 +1,54 @@
+package steampipecloud
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+)
+
+func TestResolveIdentityDeduplicatesLookups(t *testing.T) {
+	m := newFixtureCloud(t)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	p := Plugin(ctx)
+	table := p.TableMap["steampipecloud_workspace_snapshot"]
+	table.Plugin = p
+	d, err := m.newQueryData(t, table, nil, tableQuery{table: table.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			identity, err := resolveIdentity(ctx, d, &plugin.HydrateData{}, "o_acme")
+			if err == nil && identity.Handle != "acme" {
+				t.Errorf("expected identity acme, got %s", identity.Handle)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// resolving by handle uses the identity cached when resolving by id
+	if _, err := resolveIdentity(ctx, d, &plugin.HydrateData{}, "acme"); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := m.requestCount("identity/acme"); count != 1 {
+		t.Errorf("expected a single identity lookup, got %d", count)
+	}
+}
@@ -0,0 +1,339 @@
+package steampipecloud
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+// SnapshotPanel is a panel of a dashboard snapshot, e.g. a chart, table or
+// control, along with the results of its query.
+type SnapshotPanel struct {
+	IdentityId      string                   `json:"identity_id"`
+	IdentityHandle  string                   `json:"identity_handle"`
+	WorkspaceId     string                   `json:"workspace_id"`
+	WorkspaceHandle string                   `json:"workspace_handle"`
+	SnapshotId      string                   `json:"snapshot_id"`
+	Name            string                   `json:"name"`
+	PanelType       string                   `json:"panel_type"`
+	DisplayType     *string                  `json:"display_type"`
+	Title           *string                  `json:"title"`
+	Status          *string                  `json:"status"`
+	SQL             *string                  `json:"sql"`
+	Error           *string                  `json:"error"`
+	Columns         []interface{}            `json:"columns"`
+	RowCount        int64                    `json:"row_count"`
+	Properties      map[string]interface{}   `json:"properties"`
+	rows            []map[string]interface{} // the rows of the panel's query results
+}
+
+// SnapshotPanelRow is a single row of the query results of a snapshot panel.
+type SnapshotPanelRow struct {
+	IdentityId      string                 `json:"identity_id"`
+	IdentityHandle  string                 `json:"identity_handle"`
+	WorkspaceId     string                 `json:"workspace_id"`
+	WorkspaceHandle string                 `json:"workspace_handle"`
+	SnapshotId      string                 `json:"snapshot_id"`
+	PanelName       string                 `json:"panel_name"`
+	PanelType       string                 `json:"panel_type"`
+	RowNumber       int64                  `json:"row_number"`
+	Data            map[string]interface{} `json:"data"`
+}
+
+// snapshotContent is the downloaded payload of a snapshot, along with the
+// workspace it belongs to.
+type snapshotContent struct {
+	identity   *Identity
+	workspace  *openapi.Workspace
+	snapshotId string
+	data       openapi.WorkspaceSnapshotData
+}
+
+// getQualifiedSnapshotContent downloads the snapshot given by the snapshot_id
+// qual from the workspace streamed by the parent hydrate. It returns nil if
+// the workspace does not match the quals or the snapshot belongs to a
+// different workspace.
+func getQualifiedSnapshotContent(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (*snapshotContent, error) {
+	client, workspace, err := connectQualifiedWorkspace(ctx, d, h)
+	if err != nil || client == nil {
+		return nil, err
+	}
+	return downloadSnapshotContent(ctx, d, h, client, workspace, d.EqualsQuals["snapshot_id"].GetStringValue())
+}
+
+// connectQualifiedWorkspace connects to the identity which owns the workspace
+// streamed by the parent hydrate. It returns a nil client if the workspace
+// does not match the quals.
+func connectQualifiedWorkspace(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (*identityClient, *openapi.Workspace, error) {
+	workspace := workspaceFromItem(h.Item)
+	if workspace == nil {
+		plugin.Logger(ctx).Debug("connectQualifiedWorkspace", "Unknown Type", h.Item)
+		return nil, nil, nil
+	}
+
+	client, err := connectIdentity(ctx, d, h, workspace.IdentityId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Skip workspaces other than the one asked for
+	if !workspaceMatchesQuals(d, client.identity, workspace) {
+		return nil, nil, nil
+	}
+	return client, workspace, nil
+}
+
+// downloadSnapshotContent downloads a snapshot of the workspace. It returns
+// nil if the snapshot belongs to a different workspace. The payload is shared
+// with other tables and columns which use the same version of the snapshot.
+//
+// Unless the workspace is given in the quals, every workspace is asked for
+// the snapshot, so workspaces which forbid access are skipped rather than
+// failing the query.
+func downloadSnapshotContent(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, client *identityClient, workspace *openapi.Workspace, snapshotId string) (*snapshotContent, error) {
+	// The version of the snapshot is needed to find its cached payload
+	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+		return client.getWorkspaceSnapshot(ctx, workspace.Handle, snapshotId)
+	}
+
+	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
+	if err != nil {
+		if skipSnapshotError(d, err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	data, err := getSnapshotPayload(ctx, d, h, client, workspace.Handle, response.(openapi.WorkspaceSnapshot))
+	if err != nil {
+		if skipSnapshotError(d, err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &snapshotContent{
+		identity:   client.identity,
+		workspace:  workspace,
+		snapshotId: snapshotId,
+		data:       *data,
+	}, nil
+}
+
+// skipSnapshotError returns true if the snapshot is not in the workspace, or
+// the workspace forbids access while searching all workspaces for it.
+func skipSnapshotError(d *plugin.QueryData, err error) bool {
+	if isAPIError(err, apiErrorNotFound) {
+		return true
+	}
+	identity, workspace := workspaceQuals(d)
+	return (identity == "" || workspace == "") && isAPIError(err, apiErrorForbidden)
+}
+
+// panels returns the panels of the snapshot, ordered by name.
+func (c *snapshotContent) panels() []*SnapshotPanel {
+	var names []string
+	for name := range c.data.Panels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var panels []*SnapshotPanel
+	for _, name := range names {
+		raw, ok := c.data.Panels[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		panels = append(panels, c.newPanel(name, raw))
+	}
+	return panels
+}
+
+func (c *snapshotContent) newPanel(name string, raw map[string]interface{}) *SnapshotPanel {
+	panel := &SnapshotPanel{
+		IdentityId:      c.identity.Id,
+		IdentityHandle:  c.identity.Handle,
+		WorkspaceId:     c.workspace.Id,
+		WorkspaceHandle: c.workspace.Handle,
+		SnapshotId:      c.snapshotId,
+		Name:            name,
+		PanelType:       stringField(raw, "panel_type"),
+		DisplayType:     optionalStringField(raw, "display_type"),
+		Title:           optionalStringField(raw, "title"),
+		Status:          optionalStringField(raw, "status"),
+		SQL:             optionalStringField(raw, "sql"),
+		Error:           optionalStringField(raw, "error"),
+	}
+	if properties, ok := raw["properties"].(map[string]interface{}); ok {
+		panel.Properties = properties
+	}
+
+	if data, ok := raw["data"].(map[string]interface{}); ok {
+		if columns, ok := data["columns"].([]interface{}); ok {
+			panel.Columns = columns
+		}
+		if rows, ok := data["rows"].([]interface{}); ok {
+			for _, row := range rows {
+				if values, ok := row.(map[string]interface{}); ok {
+					panel.rows = append(panel.rows, values)
+				}
+			}
+		}
+	}
+	panel.RowCount = int64(len(panel.rows))
+
+	return panel
+}
+
+// resultRows returns the rows of the query results of the panel.
+func (panel *SnapshotPanel) resultRows() []*SnapshotPanelRow {
+	var rows []*SnapshotPanelRow
+	for i, values := range panel.rows {
+		rows = append(rows, &SnapshotPanelRow{
+			IdentityId:      panel.IdentityId,
+			IdentityHandle:  panel.IdentityHandle,
+			WorkspaceId:     panel.WorkspaceId,
+			WorkspaceHandle: panel.WorkspaceHandle,
+			SnapshotId:      panel.SnapshotId,
+			PanelName:       panel.Name,
+			PanelType:       panel.PanelType,
+			RowNumber:       int64(i + 1),
+			Data:            values,
+		})
+	}
+	return rows
+}
+
+func stringField(values map[string]interface{}, name string) string {
+	if s, ok := values[name].(string); ok {
+		return s
+	}
+	return ""
+}
+
+func optionalStringField(values map[string]interface{}, name string) *string {
+	if s, ok := values[name].(string); ok && s != "" {
+		return &s
+	}
+	return nil
+}
+
+// SnapshotControlResult is the result of a benchmark control for a single
+// resource.
+type SnapshotControlResult struct {
+	IdentityId      string                 `json:"identity_id"`
+	IdentityHandle  string                 `json:"identity_handle"`
+	WorkspaceId     string                 `json:"workspace_id"`
+	WorkspaceHandle string                 `json:"workspace_handle"`
+	SnapshotId      string                 `json:"snapshot_id"`
+	BenchmarkName   *string                `json:"benchmark_name"`
+	BenchmarkPath   []string               `json:"benchmark_path"`
+	ControlName     string                 `json:"control_name"`
+	ControlTitle    *string                `json:"control_title"`
+	Severity        *string                `json:"severity"`
+	Tags            map[string]interface{} `json:"tags"`
+	Resource        *string                `json:"resource"`
+	Status          *string                `json:"status"`
+	Reason          *string                `json:"reason"`
+	Dimensions      map[string]interface{} `json:"dimensions"`
+}
+
+// controlResultColumns are the columns of a control's results which are not
+// dimensions.
+var controlResultColumns = map[string]bool{
+	"resource": true,
+	"status":   true,
+	"reason":   true,
+}
+
+// controlResults returns the results of each control in the snapshot, in the
+// order they appear in the benchmark tree. The benchmark path of a control is
+// the benchmarks above it, from the top. A control in several benchmarks has
+// its results once for each benchmark path.
+func (c *snapshotContent) controlResults() []*SnapshotControlResult {
+	var results []*SnapshotControlResult
+	// controls seen in the layout, by name and by benchmark path and name
+	visited := map[string]bool{}
+	visitedPaths := map[string]bool{}
+
+	var walk func(layout openapi.WorkspaceSnapshotDataLayout, path []string)
+	walk = func(layout openapi.WorkspaceSnapshotDataLayout, path []string) {
+		switch layout.PanelType {
+		case "benchmark":
+			path = append(append([]string{}, path...), layout.Name)
+		case "control":
+			visited[layout.Name] = true
+			key := strings.Join(append(append([]string{}, path...), layout.Name), "\x00")
+			if !visitedPaths[key] {
+				visitedPaths[key] = true
+				results = append(results, c.newControlResults(layout.Name, path)...)
+			}
+		}
+		if layout.Children != nil {
+			for _, child := range *layout.Children {
+				walk(child, path)
+			}
+		}
+	}
+	walk(c.data.Layout, nil)
+
+	// Include any controls missing from the layout, without a benchmark
+	var names []string
+	for name, panel := range c.data.Panels {
+		if raw, ok := panel.(map[string]interface{}); ok && stringField(raw, "panel_type") == "control" && !visited[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		results = append(results, c.newControlResults(name, nil)...)
+	}
+
+	return results
+}
+
+func (c *snapshotContent) newControlResults(controlName string, benchmarkPath []string) []*SnapshotControlResult {
+	raw, ok := c.data.Panels[controlName].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	panel := c.newPanel(controlName, raw)
+
+	var benchmarkName *string
+	if len(benchmarkPath) > 0 {
+		benchmarkName = &benchmarkPath[len(benchmarkPath)-1]
+	}
+	tags, _ := raw["tags"].(map[string]interface{})
+
+	var results []*SnapshotControlResult
+	for _, row := range panel.rows {
+		result := &SnapshotControlResult{
+			IdentityId:      panel.IdentityId,
+			IdentityHandle:  panel.IdentityHandle,
+			WorkspaceId:     panel.WorkspaceId,
+			WorkspaceHandle: panel.WorkspaceHandle,
+			SnapshotId:      panel.SnapshotId,
+			BenchmarkName:   benchmarkName,
+			BenchmarkPath:   benchmarkPath,
+			ControlName:     controlName,
+			ControlTitle:    panel.Title,
+			Severity:        optionalStringField(raw, "severity"),
+			Tags:            tags,
+			Resource:        optionalStringField(row, "resource"),
+			Status:          optionalStringField(row, "status"),
+			Reason:          optionalStringField(row, "reason"),
+			Dimensions:      map[string]interface{}{},
+		}
+		for column, value := range row {
+			if !controlResultColumns[column] {
+				result.Dimensions[column] = value
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
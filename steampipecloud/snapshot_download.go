@@ -0,0 +1,239 @@
+package steampipecloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+const (
+	// defaultMaxSnapshotSizeBytes is the largest snapshot downloaded if the
+	// max_snapshot_size_bytes config is not set.
+	defaultMaxSnapshotSizeBytes = 100 * 1024 * 1024
+
+	// maxCachedSnapshotSizeBytes is the largest snapshot kept in the
+	// connection cache once downloaded. The cache has no bound on its total
+	// size, so larger snapshots are downloaded again by each query using them.
+	maxCachedSnapshotSizeBytes = 10 * 1024 * 1024
+
+	// snapshotFormatJSON is the format of the snapshot payload, which is
+	// downloaded if no other format is asked for.
+	snapshotFormatJSON = "json"
+)
+
+// snapshotFormatContentTypes are the formats a snapshot can be downloaded in,
+// and the content type accepted for each. Not every dashboard can be exported
+// in every format, and the API returns an error for those which cannot.
+var snapshotFormatContentTypes = map[string]string{
+	snapshotFormatJSON: "application/json",
+	"csv":              "text/csv",
+	"html":             "text/html",
+	"md":               "text/markdown",
+}
+
+// SnapshotExport is a snapshot downloaded in a format such as csv or html,
+// e.g. for archiving reports.
+type SnapshotExport struct {
+	ContentType *string `json:"content_type"`
+	DataText    string  `json:"data_text"`
+}
+
+// maxSnapshotSizeBytes returns the largest snapshot which may be downloaded,
+// or 0 if there is no limit.
+func maxSnapshotSizeBytes(d *plugin.QueryData) int64 {
+	config := GetConfig(d.Connection)
+	if config.MaxSnapshotSizeBytes != nil {
+		if *config.MaxSnapshotSizeBytes < 0 {
+			return 0
+		}
+		return int64(*config.MaxSnapshotSizeBytes)
+	}
+	return defaultMaxSnapshotSizeBytes
+}
+
+// snapshotPayload is a decoded snapshot payload, and the number of bytes it
+// was decoded from.
+type snapshotPayload struct {
+	data      *openapi.WorkspaceSnapshotData
+	sizeBytes int64
+}
+
+// getSnapshotPayload downloads and decodes the JSON payload of a snapshot.
+// Payloads up to maxCachedSnapshotSizeBytes are cached by snapshot id and
+// version, so each version is only downloaded once however many columns and
+// tables of a query use it. Callers must not modify the payload.
+func getSnapshotPayload(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, client *identityClient, workspaceHandle string, snapshot openapi.WorkspaceSnapshot) (*openapi.WorkspaceSnapshotData, error) {
+	cacheKey := fmt.Sprintf("snapshot_payload/%s/%d", snapshot.Id, snapshot.VersionId)
+	payload, err := memoizeLookupIf(ctx, d, cacheKey, func() (*snapshotPayload, error) {
+		maxSize := maxSnapshotSizeBytes(d)
+		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+			resp, err := client.openWorkspaceSnapshotDownload(ctx, http.MethodGet, workspaceHandle, snapshot.Id, snapshotFormatJSON)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			return decodeSnapshotPayload(resp, snapshot.Id, maxSize)
+		}
+
+		response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
+		if err != nil {
+			return nil, err
+		}
+		return response.(*snapshotPayload), nil
+	}, func(payload *snapshotPayload) bool {
+		return payload.sizeBytes <= maxCachedSnapshotSizeBytes
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payload.data, nil
+}
+
+// decodeSnapshotPayload decodes the payload of a snapshot as it is read from
+// the response, failing once more than maxSize bytes have been read rather
+// than reading the whole payload into memory first.
+func decodeSnapshotPayload(resp *http.Response, snapshotId string, maxSize int64) (*snapshotPayload, error) {
+	if maxSize > 0 && resp.ContentLength > maxSize {
+		return nil, snapshotTooLargeError(snapshotId, maxSize)
+	}
+
+	body := &countingReader{r: resp.Body}
+	var decoded io.Reader = body
+	if maxSize > 0 {
+		decoded = io.LimitReader(body, maxSize+1)
+	}
+
+	var data openapi.WorkspaceSnapshotData
+	err := json.NewDecoder(decoded).Decode(&data)
+	// the limit was reached, so the payload is larger than maxSize even if
+	// it was decoded from the bytes read so far
+	if maxSize > 0 && body.n > maxSize {
+		return nil, snapshotTooLargeError(snapshotId, maxSize)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload for snapshot %s: %v", snapshotId, err)
+	}
+	return &snapshotPayload{data: &data, sizeBytes: body.n}, nil
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// getSnapshotExport downloads a snapshot in the given format. Like the JSON
+// payload, it is cached by snapshot id and version if it is no larger than
+// maxCachedSnapshotSizeBytes.
+func getSnapshotExport(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, client *identityClient, workspaceHandle string, snapshot openapi.WorkspaceSnapshot, format string) (*SnapshotExport, error) {
+	cacheKey := fmt.Sprintf("snapshot_export/%s/%d/%s", snapshot.Id, snapshot.VersionId, format)
+	return memoizeLookupIf(ctx, d, cacheKey, func() (*SnapshotExport, error) {
+		maxSize := maxSnapshotSizeBytes(d)
+		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+			resp, err := client.openWorkspaceSnapshotDownload(ctx, http.MethodGet, workspaceHandle, snapshot.Id, format)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			return readSnapshotExport(resp, snapshot.Id, maxSize)
+		}
+
+		response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
+		if err != nil {
+			return nil, err
+		}
+		return response.(*SnapshotExport), nil
+	}, func(export *SnapshotExport) bool {
+		return len(export.DataText) <= maxCachedSnapshotSizeBytes
+	})
+}
+
+// readSnapshotExport reads a snapshot export from the response, failing once
+// more than maxSize bytes have been read.
+func readSnapshotExport(resp *http.Response, snapshotId string, maxSize int64) (*SnapshotExport, error) {
+	if maxSize > 0 && resp.ContentLength > maxSize {
+		return nil, snapshotTooLargeError(snapshotId, maxSize)
+	}
+
+	var body io.Reader = resp.Body
+	if maxSize > 0 {
+		body = io.LimitReader(resp.Body, maxSize+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if maxSize > 0 && int64(len(data)) > maxSize {
+		return nil, snapshotTooLargeError(snapshotId, maxSize)
+	}
+
+	export := &SnapshotExport{DataText: string(data)}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		export.ContentType = &contentType
+	}
+	return export, nil
+}
+
+// snapshotFormat returns the format given by the format qual, or json if
+// there is none.
+func snapshotFormat(d *plugin.QueryData) (string, error) {
+	format := d.EqualsQuals["format"].GetStringValue()
+	if format == "" {
+		return snapshotFormatJSON, nil
+	}
+	if _, ok := snapshotFormatContentTypes[format]; !ok {
+		var formats []string
+		for name := range snapshotFormatContentTypes {
+			formats = append(formats, name)
+		}
+		sort.Strings(formats)
+		return "", fmt.Errorf("unsupported snapshot format %q, must be one of %s", format, strings.Join(formats, ", "))
+	}
+	return format, nil
+}
+
+func snapshotTooLargeError(snapshotId string, maxSize int64) error {
+	return fmt.Errorf("snapshot %s is larger than max_snapshot_size_bytes (%d bytes)", snapshotId, maxSize)
+}
+
+// getSnapshotSize returns the size in bytes of the JSON payload of a
+// snapshot, without downloading it. It returns nil if the API does not report
+// the size, including if the snapshot is not found or the download does not
+// support HEAD requests.
+func getSnapshotSize(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, client *identityClient, workspaceHandle string, snapshot openapi.WorkspaceSnapshot) (*int64, error) {
+	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+		resp, err := client.openWorkspaceSnapshotDownload(ctx, http.MethodHead, workspaceHandle, snapshot.Id, snapshotFormatJSON)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		return resp.ContentLength, nil
+	}
+
+	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
+	if err != nil {
+		if apiErr, ok := asAPIError(err); ok && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusMethodNotAllowed) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	size := response.(int64)
+	if size < 0 {
+		return nil, nil
+	}
+	return &size, nil
+}
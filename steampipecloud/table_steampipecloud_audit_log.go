@@ -2,9 +2,6 @@ package steampipecloud
 
 import (
 	"context"
-	"strings"
-
-	openapi "github.com/turbot/steampipe-cloud-sdk-go"
 
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
@@ -17,11 +14,58 @@ func tableSteampipeCloudAuditLog(_ context.Context) *plugin.Table {
 	return &plugin.Table{
 		Name:        "steampipecloud_audit_log",
 		Description: "Audit logs record a series of events performed on an identity.",
+		DefaultIgnoreConfig: &plugin.IgnoreConfig{
+			ShouldIgnoreErrorFunc: shouldIgnoreOrgErrors,
+		},
 		List: &plugin.ListConfig{
-			Hydrate:    listAuditLogs,
-			KeyColumns: plugin.AnyColumn([]string{"identity_handle", "identity_id"}),
+			Hydrate: listAuditLogs,
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "identity_handle",
+					Require: plugin.AnyOf,
+				},
+				{
+					Name:    "identity_id",
+					Require: plugin.AnyOf,
+				},
+				{
+					Name:      "created_at",
+					Require:   plugin.Optional,
+					Operators: []string{">", ">=", "=", "<", "<="},
+				},
+				{
+					Name:      "action_type",
+					Require:   plugin.Optional,
+					Operators: []string{"=", "<>"},
+				},
+				{
+					Name:      "actor_handle",
+					Require:   plugin.Optional,
+					Operators: []string{"=", "<>"},
+				},
+				{
+					Name:      "actor_id",
+					Require:   plugin.Optional,
+					Operators: []string{"=", "<>"},
+				},
+				{
+					Name:      "target_id",
+					Require:   plugin.Optional,
+					Operators: []string{"=", "<>"},
+				},
+				{
+					Name:      "process_id",
+					Require:   plugin.Optional,
+					Operators: []string{"=", "<>"},
+				},
+				{
+					Name:       "query_where",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
+			},
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for an audit log.",
@@ -97,161 +141,54 @@ func tableSteampipeCloudAuditLog(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_STRING,
 				Transform:   transform.FromCamel(),
 			},
-		},
+			{
+				Name:        "query_where",
+				Description: "The query where expression to filter audit logs.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromQual("query_where"),
+			},
+		}),
 	}
 }
 
 //// LIST FUNCTION
 
 func listAuditLogs(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("listAuditLogs", "connection_error", err)
-		return nil, err
-	}
-
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("listAuditLogs", "getUserIdentityCached", err)
-		return nil, err
-	}
-
-	user := commonData.(openapi.User)
-
 	identityHandle := d.EqualsQuals["identity_handle"].GetStringValue()
 	identityId := d.EqualsQuals["identity_id"].GetStringValue()
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < int64(maxResults) {
-			if *limit < 1 {
-				maxResults = int32(1)
-			} else {
-				maxResults = int32(*limit)
-			}
-		}
+	identity := identityId
+	if identity == "" {
+		identity = identityHandle
 	}
-
-	if identityHandle == "" && identityId == "" {
+	if identity == "" {
 		return nil, nil
-	} else if identityId != "" && strings.HasPrefix(identityId, "u_") {
-		err = listUserAuditLogs(ctx, d, h, identityId, svc, maxResults)
-	} else if identityId != "" && strings.HasPrefix(identityId, "o_") {
-		err = listOrgAuditLogs(ctx, d, h, identityId, svc, maxResults)
-	} else if identityHandle == user.Handle {
-		err = listUserAuditLogs(ctx, d, h, identityHandle, svc, maxResults)
-	} else {
-		err = listOrgAuditLogs(ctx, d, h, identityHandle, svc, maxResults)
 	}
 
+	// Create Session
+	client, err := connectIdentity(ctx, d, h, identity)
 	if err != nil {
-		plugin.Logger(ctx).Error("listAuditLogs", "list", err)
+		plugin.Logger(ctx).Error("listAuditLogs", "connection_error", err)
 		return nil, err
 	}
-	return nil, nil
-}
-
-func listOrgAuditLogs(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, handle string, svc *openapi.APIClient, maxResults int32) error {
-	var err error
 
-	// execute list call
-	pagesLeft := true
-	var resp openapi.ListAuditLogsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.Orgs.ListAuditLogs(ctx, handle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.Orgs.ListAuditLogs(ctx, handle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listOrgAuditLogs", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListAuditLogsResponse)
-
-		if result.HasItems() {
-			for _, log := range *result.Items {
-				d.StreamListItem(ctx, log)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+	// If both quals were given they must refer to the same identity
+	if identityHandle != "" && identityHandle != client.identity.Handle {
+		return nil, nil
 	}
 
-	return nil
-}
-
-func listUserAuditLogs(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, handle string, svc *openapi.APIClient, maxResults int32) error {
-	var err error
+	// build the filter from the quals passed
+	filter, err := buildQueryFilter(d, "identity_handle", "identity_id")
+	if err != nil {
+		plugin.Logger(ctx).Error("listAuditLogs", "filter_error", err)
+		return nil, err
+	}
 
 	// execute list call
-	pagesLeft := true
-	var resp openapi.ListAuditLogsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.Users.ListAuditLogs(ctx, handle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.Users.ListAuditLogs(ctx, handle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listUserAuditLogs", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListAuditLogsResponse)
-
-		if result.HasItems() {
-			for _, log := range *result.Items {
-				d.StreamListItem(ctx, log)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if resp.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+	err = paginate(ctx, d, h, client.listAuditLogs(filter))
+	if err != nil {
+		plugin.Logger(ctx).Error("listAuditLogs", "list", err)
+		return nil, err
 	}
-
-	return nil
+	return nil, nil
 }
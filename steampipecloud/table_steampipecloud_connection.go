@@ -2,7 +2,6 @@ package steampipecloud
 
 import (
 	"context"
-	"strings"
 
 	openapi "github.com/turbot/steampipe-cloud-sdk-go"
 
@@ -34,7 +33,7 @@ func tableSteampipeCloudConnection(_ context.Context) *plugin.Table {
 			KeyColumns: plugin.AllColumns([]string{"handle", "identity_handle"}),
 			Hydrate:    getConnection,
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for the connection.",
@@ -117,207 +116,70 @@ func tableSteampipeCloudConnection(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
 //// LIST FUNCTION
 
 func listConnections(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("listConnections", "connection_error", err)
-		return nil, err
-	}
-
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("listConnections", "getUserIdentityCached", err)
-		return nil, err
-	}
-
-	user := commonData.(openapi.User)
-
 	identityHandle := d.EqualsQuals["identity_handle"].GetStringValue()
 	identityId := d.EqualsQuals["identity_id"].GetStringValue()
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < int64(maxResults) {
-			if *limit < 1 {
-				maxResults = int32(1)
-			} else {
-				maxResults = int32(*limit)
-			}
+	if identityHandle == "" && identityId == "" {
+		// Create Session
+		svc, err := connect(ctx, d)
+		if err != nil {
+			plugin.Logger(ctx).Error("listConnections", "connection_error", err)
+			return nil, err
 		}
+		err = listActorConnections(ctx, d, h, svc)
+		if err != nil {
+			plugin.Logger(ctx).Error("listConnections", "list", err)
+			return nil, err
+		}
+		return nil, nil
 	}
 
-	if identityHandle == "" && identityId == "" {
-		err = listActorConnections(ctx, d, h, svc, maxResults)
-	} else if identityId != "" && strings.HasPrefix(identityId, "u_") {
-		err = listUserConnections(ctx, d, h, identityId, svc, maxResults)
-	} else if identityId != "" && strings.HasPrefix(identityId, "o_") {
-		err = listOrgConnections(ctx, d, h, identityId, svc, maxResults)
-	} else if identityHandle == user.Handle {
-		err = listUserConnections(ctx, d, h, identityHandle, svc, maxResults)
-	} else {
-		err = listOrgConnections(ctx, d, h, identityHandle, svc, maxResults)
+	identity := identityId
+	if identity == "" {
+		identity = identityHandle
 	}
 
+	client, err := connectIdentity(ctx, d, h, identity)
 	if err != nil {
-		plugin.Logger(ctx).Error("listConnections", "list", err)
+		plugin.Logger(ctx).Error("listConnections", "connection_error", err)
 		return nil, err
 	}
-	return nil, nil
-}
-
-func listOrgConnections(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, handle string, svc *openapi.APIClient, maxResults int32) error {
-	var err error
-
-	// execute list call
-	pagesLeft := true
-	var resp openapi.ListConnectionsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgConnections.List(ctx, handle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgConnections.List(ctx, handle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listOrgConnections", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListConnectionsResponse)
-
-		for _, connection := range *result.Items {
-			d.StreamListItem(ctx, connection)
 
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+	// If both quals were given they must refer to the same identity
+	if identityHandle != "" && identityHandle != client.identity.Handle {
+		return nil, nil
 	}
 
-	return nil
-}
-
-func listUserConnections(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, handle string, svc *openapi.APIClient, maxResults int32) error {
-	var err error
-
 	// execute list call
-	pagesLeft := true
-	var resp openapi.ListConnectionsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserConnections.List(ctx, handle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserConnections.List(ctx, handle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listUserConnections", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListConnectionsResponse)
-
-		for _, connection := range *result.Items {
-			d.StreamListItem(ctx, connection)
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+	err = paginate(ctx, d, h, client.listConnections)
+	if err != nil {
+		plugin.Logger(ctx).Error("listConnections", "list", err)
+		return nil, err
 	}
-
-	return nil
+	return nil, nil
 }
 
-func listActorConnections(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, svc *openapi.APIClient, maxResults int32) error {
-	var err error
-
+func listActorConnections(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, svc *openapi.APIClient) error {
 	// execute list call
-	pagesLeft := true
-
-	var resp openapi.ListConnectionsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.Actors.ListConnections(ctx).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.Actors.ListConnections(ctx).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listActorConnections", "list", err)
-			return err
+	err := paginate(ctx, d, h, func(ctx context.Context, nextToken *string, limit int32) ([]openapi.Connection, *string, error) {
+		req := svc.Actors.ListConnections(ctx).Limit(limit)
+		if nextToken != nil {
+			req = req.NextToken(*nextToken)
 		}
+		resp, _, err := req.Execute()
+		return resp.GetItems(), resp.NextToken, err
+	})
 
-		result := response.(openapi.ListConnectionsResponse)
-
-		if result.HasItems() {
-			for _, connection := range *result.Items {
-				d.StreamListItem(ctx, connection)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+	if err != nil {
+		plugin.Logger(ctx).Error("listActorConnections", "list", err)
+		return err
 	}
 
 	return nil
@@ -335,94 +197,27 @@ func getConnection(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateDa
 	}
 
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, identityHandle)
 	if err != nil {
 		plugin.Logger(ctx).Error("getConnection", "connection_error", err)
 		return nil, err
 	}
 
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("getConnection", "getUserIdentityCached", err)
-		return nil, err
-	}
-
-	user := commonData.(openapi.User)
-	var resp interface{}
-
-	if identityHandle == user.Handle {
-		resp, err = getUserConnection(ctx, d, h, identityHandle, handle, svc)
-	} else {
-		resp, err = getOrgConnection(ctx, d, h, identityHandle, handle, svc)
-	}
-
-	if err != nil {
-		plugin.Logger(ctx).Error("getConnection", "get", err)
-		return nil, err
-	}
-
-	if resp == nil {
-		return nil, nil
-	}
-
-	return resp.(openapi.Connection), nil
-}
-
-func getOrgConnection(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, identityHandle string, handle string, svc *openapi.APIClient) (interface{}, error) {
-	var err error
-
 	// execute get call
-	var resp openapi.Connection
-
-	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		resp, _, err = svc.OrgConnections.Get(ctx, identityHandle, handle).Execute()
-		return resp, err
-	}
-
-	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-	connection := response.(openapi.Connection)
-
-	if err != nil {
-		plugin.Logger(ctx).Error("getOrgConnection", "get", err)
-		return nil, err
-	}
-
-	return connection, nil
-}
-
-func getUserConnection(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, identityHandle string, handle string, svc *openapi.APIClient) (interface{}, error) {
-	var err error
-
-	// execute get call
-	var resp openapi.Connection
-
 	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		resp, _, err = svc.UserConnections.Get(ctx, identityHandle, handle).Execute()
-		return resp, err
+		return client.getConnection(ctx, handle)
 	}
 
 	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-	connection := response.(openapi.Connection)
-
 	if err != nil {
-		plugin.Logger(ctx).Error("getUserConnection", "get", err)
+		plugin.Logger(ctx).Error("getConnection", "get", err)
 		return nil, err
 	}
 
-	return connection, nil
+	return response.(openapi.Connection), nil
 }
 
 func getIdentityDetailsForConnection(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("getIdentityDetailsForConnection", "connection_error", err)
-		return nil, err
-	}
-
 	// Get the identity id from the connection hydrate object
 	var identityId string
 	switch w := h.Item.(type) {
@@ -434,13 +229,11 @@ func getIdentityDetailsForConnection(ctx context.Context, d *plugin.QueryData, h
 		plugin.Logger(ctx).Debug("getIdentityDetailsForConnection", "Unknown Type", w)
 	}
 
-	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		resp, _, err := svc.Identities.Get(ctx, identityId).Execute()
-		return resp, err
+	identity, err := resolveIdentity(ctx, d, h, identityId)
+	if err != nil {
+		plugin.Logger(ctx).Error("getIdentityDetailsForConnection", "resolveIdentity", err)
+		return nil, err
 	}
 
-	response, _ := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-	identity := response.(openapi.Identity)
-
 	return &IdentityDetails{IdentityHandle: identity.Handle, IdentityType: identity.Type}, nil
 }
@@ -17,10 +17,43 @@ func tableSteampipeCloudWorkspaceDBLog(_ context.Context) *plugin.Table {
 		Name:        "steampipecloud_workspace_db_log",
 		Description: "Database logs records the underlying queries executed when a user executes a query.",
 		List: &plugin.ListConfig{
-			ParentHydrate: listWorkspaces,
+			ParentHydrate: listQualifiedWorkspaces,
 			Hydrate:       listWorkspaceDBLogs,
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "identity_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "identity_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:      "log_timestamp",
+					Require:   plugin.Optional,
+					Operators: []string{">", ">=", "=", "<", "<="},
+				},
+				{
+					Name:      "actor_handle",
+					Require:   plugin.Optional,
+					Operators: []string{"=", "<>"},
+				},
+				{
+					Name:      "duration",
+					Require:   plugin.Optional,
+					Operators: []string{">", ">=", "=", "<", "<="},
+				},
+			},
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for a db log.",
@@ -49,6 +82,20 @@ func tableSteampipeCloudWorkspaceDBLog(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_STRING,
 				Transform:   transform.FromCamel(),
 			},
+			{
+				Name:        "identity_id",
+				Description: "The unique identifier of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getDBLogIdentity,
+				Transform:   transform.FromField("Id"),
+			},
+			{
+				Name:        "identity_handle",
+				Description: "The handle of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getDBLogIdentity,
+				Transform:   transform.FromField("Handle"),
+			},
 			{
 				Name:        "workspace_id",
 				Description: "The unique identifier of the workspace on which the query was executed.",
@@ -71,6 +118,34 @@ func tableSteampipeCloudWorkspaceDBLog(_ context.Context) *plugin.Table {
 				Description: "The query that was executed in the workspace.",
 				Type:        proto.ColumnType_STRING,
 			},
+			{
+				Name:        "query_fingerprint",
+				Description: "A hash of the normalized query, which is the same for queries that differ only by their literal values.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getDBLogQueryShape,
+				Transform:   transform.FromField("Fingerprint"),
+			},
+			{
+				Name:        "query_normalized",
+				Description: "The query with comments removed, whitespace collapsed and literal values replaced by ?.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getDBLogQueryShape,
+				Transform:   transform.FromField("Normalized"),
+			},
+			{
+				Name:        "tables_referenced",
+				Description: "The tables read or written by the query, e.g. aws_s3_bucket or aws.aws_s3_bucket.",
+				Type:        proto.ColumnType_JSON,
+				Hydrate:     getDBLogQueryShape,
+				Transform:   transform.FromField("TablesReferenced"),
+			},
+			{
+				Name:        "statement_type",
+				Description: "The type of the query's statement, e.g. select.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getDBLogQueryShape,
+				Transform:   transform.FromField("StatementType"),
+			},
 			{
 				Name:        "log_timestamp",
 				Description: "The time when the log got captured in postgres.",
@@ -81,7 +156,7 @@ func tableSteampipeCloudWorkspaceDBLog(_ context.Context) *plugin.Table {
 				Description: "The time when the db log record was generated.",
 				Type:        proto.ColumnType_STRING,
 			},
-		},
+		}),
 	}
 }
 
@@ -89,154 +164,69 @@ func tableSteampipeCloudWorkspaceDBLog(_ context.Context) *plugin.Table {
 
 func listWorkspaceDBLogs(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	// Get the workspace object from the parent hydrate
-	workspace := h.Item.(*openapi.Workspace)
-
-	// Create the connection
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("listDBLogs", "connection_error", err)
-		return nil, err
+	var workspace *openapi.Workspace
+	switch w := h.Item.(type) {
+	case openapi.Workspace:
+		workspace = &w
+	case *openapi.Workspace:
+		workspace = w
+	default:
+		plugin.Logger(ctx).Debug("listWorkspaceDBLogs", "Unknown Type", w)
+		return nil, nil
 	}
 
-	// Get cached user identity
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
+	// Create the connection
+	client, err := connectIdentity(ctx, d, h, workspace.IdentityId)
 	if err != nil {
-		plugin.Logger(ctx).Error("listDBLogs", "getUserIdentityCached", err)
+		plugin.Logger(ctx).Error("listWorkspaceDBLogs", "connection_error", err)
 		return nil, err
 	}
 
-	// Extract the user object from the cached identity
-	user := commonData.(openapi.User)
-
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < int64(maxResults) {
-			if *limit < 1 {
-				maxResults = int32(1)
-			} else {
-				maxResults = int32(*limit)
-			}
-		}
+	// Skip workspaces other than the one asked for
+	if !workspaceMatchesQuals(d, client.identity, workspace) {
+		return nil, nil
 	}
 
-	// If we want to get the db logs for the user
-	if user.Id == workspace.IdentityId {
-		err = listUserWorkspaceDBLogs(ctx, d, h, svc, maxResults, user.Id, workspace.Id)
-	} else {
-		err = listOrgWorkspaceDBLogs(ctx, d, h, svc, maxResults, workspace.IdentityId, workspace.Id)
-	}
+	// build the filter from the quals passed, excluding those used to select the workspace
+	filter, err := buildQueryFilter(d, "identity_id", "identity_handle", "workspace_id", "workspace_handle")
 	if err != nil {
-		plugin.Logger(ctx).Error("listDBLogs", "error", err)
+		plugin.Logger(ctx).Error("listWorkspaceDBLogs", "filter_error", err)
 		return nil, err
 	}
 
+	// execute list call
+	err = paginate(ctx, d, h, client.listWorkspaceDBLogs(workspace.Id, filter))
 	if err != nil {
-		plugin.Logger(ctx).Error("listDBLogs", "error", err)
+		plugin.Logger(ctx).Error("listWorkspaceDBLogs", "list", err)
 		return nil, err
 	}
 	return nil, nil
 }
 
-func listUserWorkspaceDBLogs(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, svc *openapi.APIClient, maxResults int32, identityId, workspaceId string) error {
-	var err error
-
-	// execute list call
-	pagesLeft := true
-	var resp openapi.ListLogsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaces.ListDBLogs(ctx, identityId, workspaceId).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaces.ListDBLogs(ctx, identityId, workspaceId).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listUserDBLogs", "list", err)
-			return err
-		}
+//// HYDRATE FUNCTIONS
 
-		result := response.(openapi.ListLogsResponse)
-
-		if result.HasItems() {
-			for _, log := range *result.Items {
-				d.StreamListItem(ctx, log)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if resp.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+// getDBLogIdentity returns the identity which owns the workspace of the db
+// log, which is the parent item.
+func getDBLogIdentity(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	workspace := workspaceFromItem(h.ParentItem)
+	if workspace == nil {
+		return nil, nil
 	}
 
-	return nil
+	identity, err := resolveIdentity(ctx, d, h, workspace.IdentityId)
+	if err != nil {
+		plugin.Logger(ctx).Error("getDBLogIdentity", "query_error", err)
+		return nil, err
+	}
+	return identity, nil
 }
 
-func listOrgWorkspaceDBLogs(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, svc *openapi.APIClient, maxResults int32, identityId, workspaceId string) error {
-	var err error
-
-	// execute list call
-	pagesLeft := true
-	var resp openapi.ListLogsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaces.ListDBLogs(ctx, identityId, workspaceId).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaces.ListDBLogs(ctx, identityId, workspaceId).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listOrgDBLogs", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListLogsResponse)
-
-		if result.HasItems() {
-			for _, log := range *result.Items {
-				d.StreamListItem(ctx, log)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+// getDBLogQueryShape returns the shape of the db log's query, used to group
+// queries which differ only by their literal values.
+func getDBLogQueryShape(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logRecord := h.Item.(openapi.LogRecord)
+	if logRecord.Query == nil {
+		return nil, nil
 	}
-
-	return nil
+	return analyzeQuery(*logRecord.Query), nil
 }
@@ -0,0 +1,392 @@
+package steampipecloud
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+const (
+	inviteTypeOrg       = "org"
+	inviteTypeWorkspace = "workspace"
+
+	// inviteStaleAge is the age after which an invite without an expiry time
+	// is considered stale.
+	inviteStaleAge = 7 * 24 * time.Hour
+)
+
+// Invite is an org or workspace membership which has not been accepted yet.
+type Invite struct {
+	Id              string        `json:"id"`
+	InviteType      string        `json:"invite_type"`
+	OrgId           string        `json:"org_id"`
+	OrgHandle       string        `json:"org_handle"`
+	WorkspaceId     *string       `json:"workspace_id"`
+	WorkspaceHandle *string       `json:"workspace_handle"`
+	UserId          string        `json:"user_id"`
+	UserHandle      string        `json:"user_handle"`
+	InviteeEmail    *string       `json:"invitee_email"`
+	Role            *string       `json:"role"`
+	Scope           *string       `json:"scope"`
+	Status          string        `json:"status"`
+	CreatedAt       string        `json:"created_at"`
+	CreatedById     string        `json:"created_by_id"`
+	CreatedBy       *openapi.User `json:"created_by"`
+	UpdatedAt       *string       `json:"updated_at"`
+	ExpiresAt       *string       `json:"expires_at"`
+	AgeDays         *float64      `json:"age_days"`
+	IsStale         bool          `json:"is_stale"`
+	VersionId       int32         `json:"version_id"`
+}
+
+// inviteMember is an org or workspace member as returned by the API. The
+// email and expiry of an invite are not part of the openapi models, so the
+// member lists are decoded here to pick them up when the API returns them.
+type inviteMember struct {
+	openapi.OrgWorkspaceUser
+	Email     *string `json:"email,omitempty"`
+	ExpiresAt *string `json:"expires_at,omitempty"`
+}
+
+type listInviteMembersResponse struct {
+	Items     []inviteMember `json:"items"`
+	NextToken *string        `json:"next_token,omitempty"`
+}
+
+//// TABLE DEFINITION
+
+func tableSteampipeCloudInvite(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "steampipecloud_invite",
+		Description: "Invites to organizations and organization workspaces which have not been accepted yet.",
+		DefaultIgnoreConfig: &plugin.IgnoreConfig{
+			ShouldIgnoreErrorFunc: shouldIgnoreOrgErrors,
+		},
+		List: &plugin.ListConfig{
+			ParentHydrate: listOrganizations,
+			Hydrate:       listInvites,
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "org_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "invite_type",
+					Require: plugin.Optional,
+				},
+			},
+		},
+		Get: &plugin.GetConfig{
+			KeyColumns: plugin.SingleColumn("id"),
+			Hydrate:    getInvite,
+		},
+		Columns: commonColumns([]*plugin.Column{
+			{
+				Name:        "id",
+				Description: "The unique identifier for the invite.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "invite_type",
+				Description: "The type of the invite, either org or workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "org_id",
+				Description: "The unique identifier for the organization.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "org_handle",
+				Description: "The handle of the organization.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "workspace_id",
+				Description: "The unique identifier for the workspace, for workspace invites.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "workspace_handle",
+				Description: "The handle of the workspace, for workspace invites.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "user_id",
+				Description: "The unique identifier of the invited user.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "user_handle",
+				Description: "The handle of the invited user.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "invitee_email",
+				Description: "The email address the invite was sent to, if returned by the API.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "role",
+				Description: "The role the user is invited to.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "scope",
+				Description: "The scope of the role, either org or workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "status",
+				Description: "The status of the invite, e.g. invited or pending.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "created_at",
+				Description: "The time when the invite was created.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "created_by_id",
+				Description: "The unique identifier of the user who created the invite.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "created_by_handle",
+				Description: "The handle of the user who created the invite.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromField("CreatedBy.Handle"),
+			},
+			{
+				Name:        "created_by",
+				Description: "Information about the user who created the invite.",
+				Type:        proto.ColumnType_JSON,
+			},
+			{
+				Name:        "updated_at",
+				Description: "The time when the invite was last updated.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "expires_at",
+				Description: "The time when the invite expires, if returned by the API.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "age_days",
+				Description: "The number of days since the invite was created.",
+				Type:        proto.ColumnType_DOUBLE,
+			},
+			{
+				Name:        "is_stale",
+				Description: "True if the invite has expired, or if its expiry is unknown and it was created more than 7 days ago.",
+				Type:        proto.ColumnType_BOOL,
+			},
+			{
+				Name:        "version_id",
+				Description: "The version ID for the invite.",
+				Type:        proto.ColumnType_INT,
+				Transform:   transform.FromCamel(),
+			},
+		}),
+	}
+}
+
+//// LIST FUNCTION
+
+func listInvites(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	var org *openapi.Org
+	switch o := h.Item.(type) {
+	case openapi.Org:
+		org = &o
+	case *openapi.Org:
+		// Orgs listed via the actor are pointers
+		org = o
+	default:
+		plugin.Logger(ctx).Debug("listInvites", "Unknown Type", o)
+		return nil, nil
+	}
+
+	if orgHandle := d.EqualsQuals["org_handle"].GetStringValue(); orgHandle != "" && orgHandle != org.Handle {
+		return nil, nil
+	}
+
+	err := forEachOrgInvite(ctx, d, h, org, d.EqualsQuals["invite_type"].GetStringValue(), func(invite *Invite) bool {
+		d.StreamListItem(ctx, invite)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		return d.RowsRemaining(ctx) != 0
+	})
+	if err != nil {
+		plugin.Logger(ctx).Error("listInvites", "list", err)
+		return nil, err
+	}
+	return nil, nil
+}
+
+//// HYDRATE FUNCTIONS
+
+// getInvite finds an invite by id. The API has no lookup by invite id, so
+// the invites of each org are searched.
+func getInvite(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	id := d.EqualsQuals["id"].GetStringValue()
+	if id == "" {
+		return nil, nil
+	}
+
+	// Create Session
+	svc, err := connect(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("getInvite", "connection_error", err)
+		return nil, err
+	}
+
+	var orgs []*openapi.Org
+	err = forEachItem(ctx, d, h, defaultMaxResults, listActorOrgs(svc), func(org *openapi.Org) bool {
+		orgs = append(orgs, org)
+		return true
+	})
+	if err != nil {
+		plugin.Logger(ctx).Error("getInvite", "list_orgs", err)
+		return nil, err
+	}
+
+	var found *Invite
+	for _, org := range orgs {
+		err := forEachOrgInvite(ctx, d, h, org, "", func(invite *Invite) bool {
+			if invite.Id == id {
+				found = invite
+			}
+			return found == nil
+		})
+		if err != nil && !shouldIgnoreOrgErrors(ctx, d, h, err) {
+			plugin.Logger(ctx).Error("getInvite", "list", err)
+			return nil, err
+		}
+		if found != nil {
+			return found, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// forEachOrgInvite passes the pending invites of an org, and of each of its
+// workspaces, to fn, stopping early if fn returns false. inviteType limits
+// the invites to org or workspace invites, if set. Workspaces whose members
+// cannot be listed are skipped if shouldIgnoreOrgErrors ignores the error.
+func forEachOrgInvite(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, org *openapi.Org, inviteType string, fn func(invite *Invite) bool) error {
+	client, err := connectIdentity(ctx, d, h, org.Id)
+	if err != nil {
+		return err
+	}
+
+	stopped := false
+	visit := func(member inviteMember, workspace *openapi.Workspace) bool {
+		if member.Status == "accepted" {
+			return true
+		}
+		if !fn(newInvite(org, workspace, member, time.Now())) {
+			stopped = true
+		}
+		return !stopped
+	}
+
+	if inviteType == "" || inviteType == inviteTypeOrg {
+		path := "/org/" + url.PathEscape(org.Handle) + "/member"
+		err := forEachItem(ctx, d, h, defaultMaxResults, listInviteMembers(client.svc, "OrgMembersService.List", path), func(member inviteMember) bool {
+			return visit(member, nil)
+		})
+		if err != nil || stopped {
+			return err
+		}
+	}
+
+	if inviteType == "" || inviteType == inviteTypeWorkspace {
+		var workspaces []openapi.Workspace
+		err := forEachItem(ctx, d, h, defaultMaxResults, client.listWorkspaces, func(workspace openapi.Workspace) bool {
+			workspaces = append(workspaces, workspace)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range workspaces {
+			workspace := &workspaces[i]
+			path := "/org/" + url.PathEscape(org.Handle) + "/workspace/" + url.PathEscape(workspace.Handle) + "/member"
+			err := forEachItem(ctx, d, h, defaultMaxResults, listInviteMembers(client.svc, "OrgWorkspaceMembersService.List", path), func(member inviteMember) bool {
+				return visit(member, workspace)
+			})
+			// A workspace whose members cannot be listed is skipped like an
+			// org would be, rather than failing the invites of the whole org
+			if err != nil && !shouldIgnoreOrgErrors(ctx, d, h, err) {
+				return err
+			}
+			if stopped {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+func listInviteMembers(svc *openapi.APIClient, operation string, path string) listPageFunc[inviteMember] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]inviteMember, *string, error) {
+		var resp listInviteMembersResponse
+		err := getAPIJSON(ctx, svc, operation, path, listQuery(nextToken, limit, ""), &resp)
+		return resp.Items, resp.NextToken, err
+	}
+}
+
+func newInvite(org *openapi.Org, workspace *openapi.Workspace, member inviteMember, now time.Time) *Invite {
+	invite := &Invite{
+		Id:           member.Id,
+		InviteType:   inviteTypeOrg,
+		OrgId:        org.Id,
+		OrgHandle:    org.Handle,
+		UserId:       member.UserId,
+		UserHandle:   member.UserHandle,
+		InviteeEmail: member.Email,
+		Role:         member.Role,
+		Scope:        member.Scope,
+		Status:       member.Status,
+		CreatedAt:    member.CreatedAt,
+		CreatedById:  member.CreatedById,
+		CreatedBy:    member.CreatedBy,
+		UpdatedAt:    member.UpdatedAt,
+		ExpiresAt:    member.ExpiresAt,
+		VersionId:    member.VersionId,
+	}
+	if workspace != nil {
+		invite.InviteType = inviteTypeWorkspace
+		invite.WorkspaceId = &workspace.Id
+		invite.WorkspaceHandle = &workspace.Handle
+	}
+
+	if createdAt, err := time.Parse(time.RFC3339, member.CreatedAt); err == nil {
+		age := now.Sub(createdAt)
+		ageDays := age.Hours() / 24
+		invite.AgeDays = &ageDays
+		invite.IsStale = age > inviteStaleAge
+	}
+	if member.ExpiresAt != nil {
+		if expiresAt, err := time.Parse(time.RFC3339, *member.ExpiresAt); err == nil {
+			invite.IsStale = !now.Before(expiresAt)
+		}
+	}
+
+	return invite
+}
@@ -16,6 +16,9 @@ func tableSteampipeCloudOrganization(_ context.Context) *plugin.Table {
 	return &plugin.Table{
 		Name:        "steampipecloud_organization",
 		Description: "Organizations include multiple users and can be used to share workspaces and connections.",
+		DefaultIgnoreConfig: &plugin.IgnoreConfig{
+			ShouldIgnoreErrorFunc: shouldIgnoreOrgErrors,
+		},
 		List: &plugin.ListConfig{
 			Hydrate: listOrganizations,
 		},
@@ -23,7 +26,7 @@ func tableSteampipeCloudOrganization(_ context.Context) *plugin.Table {
 			KeyColumns: plugin.AllColumns([]string{"handle"}),
 			Hydrate:    getOrganization,
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for a organization.",
@@ -89,7 +92,7 @@ func tableSteampipeCloudOrganization(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
@@ -103,67 +106,32 @@ func listOrganizations(ctx context.Context, d *plugin.QueryData, h *plugin.Hydra
 		return nil, err
 	}
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < int64(maxResults) {
-			if *limit < 1 {
-				maxResults = int32(1)
-			} else {
-				maxResults = int32(*limit)
-			}
-		}
-	}
-
 	// execute list call
-	pagesLeft := true
-
-	var resp openapi.ListUserOrgsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.Actors.ListOrgs(ctx).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.Actors.ListOrgs(ctx).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listOrganizations", "list", err)
-			return nil, err
-		}
+	err = paginate(ctx, d, h, listActorOrgs(svc))
 
-		result := response.(openapi.ListUserOrgsResponse)
+	if err != nil {
+		plugin.Logger(ctx).Error("listOrganizations", "list", err)
+		return nil, err
+	}
 
-		if result.HasItems() {
-			for _, org := range *result.Items {
-				d.StreamListItem(ctx, org.Org)
+	return nil, nil
+}
 
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil, nil
-				}
-			}
+// listActorOrgs lists the orgs the authenticated user is a member of.
+func listActorOrgs(svc *openapi.APIClient) listPageFunc[*openapi.Org] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]*openapi.Org, *string, error) {
+		req := svc.Actors.ListOrgs(ctx).Limit(limit)
+		if nextToken != nil {
+			req = req.NextToken(*nextToken)
 		}
+		resp, _, err := req.Execute()
 
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
+		var orgs []*openapi.Org
+		for _, userOrg := range resp.GetItems() {
+			orgs = append(orgs, userOrg.Org)
 		}
+		return orgs, resp.NextToken, err
 	}
-
-	return nil, nil
 }
 
 func getOrganization(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
@@ -20,6 +20,9 @@ func tableSteampipeCloudOrganizationMember(_ context.Context) *plugin.Table {
 	return &plugin.Table{
 		Name:        "steampipecloud_organization_member",
 		Description: "Organization members can collaborate and share workspaces and connections.",
+		DefaultIgnoreConfig: &plugin.IgnoreConfig{
+			ShouldIgnoreErrorFunc: shouldIgnoreOrgErrors,
+		},
 		List: &plugin.ListConfig{
 			ParentHydrate: listOrganizations,
 			Hydrate:       listOrganizationMembers,
@@ -28,7 +31,7 @@ func tableSteampipeCloudOrganizationMember(_ context.Context) *plugin.Table {
 			KeyColumns: plugin.AllColumns([]string{"org_handle", "user_handle"}),
 			Hydrate:    getOrganizationMember,
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for the member.",
@@ -116,30 +119,17 @@ func tableSteampipeCloudOrganizationMember(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
 //// LIST FUNCTION
 
 func listOrganizationMembers(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	org := h.Item.(openapi.Org)
-
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < int64(maxResults) {
-			if *limit < 1 {
-				maxResults = int32(1)
-			} else {
-				maxResults = int32(*limit)
-			}
-		}
-	}
+	// Orgs listed via the actor are pointers
+	org := h.Item.(*openapi.Org)
 
-	err := listOrgMembers(ctx, d, h, org.Handle, maxResults)
+	err := listOrgMembers(ctx, d, h, org.Handle)
 	if err != nil {
 		plugin.Logger(ctx).Error("listOrganizationMembers", "error", err)
 		return nil, err
@@ -152,7 +142,7 @@ func listOrganizationMembers(ctx context.Context, d *plugin.QueryData, h *plugin
 	return nil, nil
 }
 
-func listOrgMembers(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, handle string, maxResults int32) error {
+func listOrgMembers(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, handle string) error {
 	// Create Session
 	svc, err := connect(ctx, d)
 	if err != nil {
@@ -160,50 +150,26 @@ func listOrgMembers(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateD
 		return err
 	}
 
-	pagesLeft := true
-	var resp openapi.ListOrgUsersResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgMembers.List(ctx, handle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgMembers.List(ctx, handle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listOrgMembers", "list", err)
-			return err
-		}
+	// execute list call
+	err = paginate(ctx, d, h, listOrgMemberPages(svc, handle))
 
-		result := response.(openapi.ListOrgUsersResponse)
+	if err != nil {
+		plugin.Logger(ctx).Error("listOrgMembers", "list", err)
+		return err
+	}
 
-		if result.HasItems() {
-			for _, member := range *result.Items {
-				d.StreamListItem(ctx, member)
+	return nil
+}
 
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
+func listOrgMemberPages(svc *openapi.APIClient, orgHandle string) listPageFunc[openapi.OrgUser] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]openapi.OrgUser, *string, error) {
+		req := svc.OrgMembers.List(ctx, orgHandle).Limit(limit)
+		if nextToken != nil {
+			req = req.NextToken(*nextToken)
 		}
+		resp, _, err := req.Execute()
+		return resp.GetItems(), resp.NextToken, err
 	}
-
-	return nil
 }
 
 func getOrganizationMember(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
@@ -246,7 +212,9 @@ func getOrgDetails(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateDa
 	// org details reside in the parent item in this case
 	switch o := h.ParentItem.(type) {
 	case openapi.Org:
-		return &OrgDetails{OrgHandle: h.ParentItem.(openapi.Org).Handle}, nil
+		return &OrgDetails{OrgHandle: o.Handle}, nil
+	case *openapi.Org:
+		return &OrgDetails{OrgHandle: o.Handle}, nil
 	default:
 		plugin.Logger(ctx).Debug("getOrgDetails", "Unknown Type", o)
 	}
@@ -2,7 +2,6 @@ package steampipecloud
 
 import (
 	"context"
-	"strings"
 
 	openapi "github.com/turbot/steampipe-cloud-sdk-go"
 
@@ -22,6 +21,9 @@ func tableSteampipeCloudOrganizationWorkspaceMember(_ context.Context) *plugin.T
 	return &plugin.Table{
 		Name:        "steampipecloud_organization_workspace_member",
 		Description: "Organization workspace members can collaborate and share connections and dashboards.",
+		DefaultIgnoreConfig: &plugin.IgnoreConfig{
+			ShouldIgnoreErrorFunc: shouldIgnoreOrgErrors,
+		},
 		List: &plugin.ListConfig{
 			ParentHydrate: listWorkspaces,
 			Hydrate:       listOrganizationWorkspaceMembers,
@@ -30,7 +32,7 @@ func tableSteampipeCloudOrganizationWorkspaceMember(_ context.Context) *plugin.T
 			KeyColumns: plugin.AllColumns([]string{"org_handle", "workspace_handle", "user_handle"}),
 			Hydrate:    getOrganizationWorkspaceMember,
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for the member.",
@@ -130,39 +132,36 @@ func tableSteampipeCloudOrganizationWorkspaceMember(_ context.Context) *plugin.T
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
 //// LIST FUNCTION
 
 func listOrganizationWorkspaceMembers(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	workspace := h.Item.(*openapi.Workspace)
-
-	if strings.HasPrefix(workspace.IdentityId, "u_") {
+	var workspace *openapi.Workspace
+	switch w := h.Item.(type) {
+	case openapi.Workspace:
+		workspace = &w
+	case *openapi.Workspace:
+		workspace = w
+	default:
+		plugin.Logger(ctx).Debug("listOrganizationWorkspaceMembers", "Unknown Type", w)
 		return nil, nil
 	}
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < int64(maxResults) {
-			if *limit < 1 {
-				maxResults = int32(1)
-			} else {
-				maxResults = int32(*limit)
-			}
-		}
-	}
-
-	err := listOrgWorkspaceMembers(ctx, d, h, workspace.IdentityId, workspace.Handle, maxResults)
+	identity, err := resolveIdentity(ctx, d, h, workspace.IdentityId)
 	if err != nil {
-		plugin.Logger(ctx).Error("listOrganizationWorkspaceMembers", "error", err)
+		plugin.Logger(ctx).Error("listOrganizationWorkspaceMembers", "resolveIdentity", err)
 		return nil, err
 	}
 
+	// Workspace members only exist for org workspaces
+	if identity.IsUser() {
+		return nil, nil
+	}
+
+	err = listOrgWorkspaceMembers(ctx, d, h, identity.Handle, workspace.Handle)
 	if err != nil {
 		plugin.Logger(ctx).Error("listOrganizationWorkspaceMembers", "error", err)
 		return nil, err
@@ -170,7 +169,7 @@ func listOrganizationWorkspaceMembers(ctx context.Context, d *plugin.QueryData,
 	return nil, nil
 }
 
-func listOrgWorkspaceMembers(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle string, workspaceHandle string, maxResults int32) error {
+func listOrgWorkspaceMembers(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle string, workspaceHandle string) error {
 	// Create Session
 	svc, err := connect(ctx, d)
 	if err != nil {
@@ -178,50 +177,26 @@ func listOrgWorkspaceMembers(ctx context.Context, d *plugin.QueryData, h *plugin
 		return err
 	}
 
-	pagesLeft := true
-	var resp openapi.ListOrgWorkspaceUsersResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaceMembers.List(ctx, orgHandle, workspaceHandle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaceMembers.List(ctx, orgHandle, workspaceHandle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
+	// execute list call
+	err = paginate(ctx, d, h, listOrgWorkspaceMemberPages(svc, orgHandle, workspaceHandle))
 
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listOrgWorkspaceMembers", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListOrgWorkspaceUsersResponse)
+	if err != nil {
+		plugin.Logger(ctx).Error("listOrgWorkspaceMembers", "list", err)
+		return err
+	}
 
-		if result.HasItems() {
-			for _, member := range *result.Items {
-				d.StreamListItem(ctx, member)
+	return nil
+}
 
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
+func listOrgWorkspaceMemberPages(svc *openapi.APIClient, orgHandle string, workspaceHandle string) listPageFunc[openapi.OrgWorkspaceUser] {
+	return func(ctx context.Context, nextToken *string, limit int32) ([]openapi.OrgWorkspaceUser, *string, error) {
+		req := svc.OrgWorkspaceMembers.List(ctx, orgHandle, workspaceHandle).Limit(limit)
+		if nextToken != nil {
+			req = req.NextToken(*nextToken)
 		}
+		resp, _, err := req.Execute()
+		return resp.GetItems(), resp.NextToken, err
 	}
-
-	return nil
 }
 
 func getOrganizationWorkspaceMember(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
@@ -261,32 +236,25 @@ func getOrganizationWorkspaceMember(ctx context.Context, d *plugin.QueryData, h
 }
 
 func getOrgWorkspaceDetails(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("getOrgDetails", "connection_error", err)
-		return nil, err
-	}
-
 	// get workspace details from hydrate data
 	// workspace details reside in the parent item in this case
+	var orgId, workspaceHandle string
 	switch w := h.ParentItem.(type) {
 	case openapi.Workspace:
-		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-			resp, _, err := svc.Orgs.Get(ctx, h.ParentItem.(openapi.Workspace).IdentityId).Execute()
-			return resp, err
-		}
-		response, _ := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-		return &OrgWorkspaceDetails{OrgHandle: response.(openapi.Org).Handle, WorkspaceHandle: w.Handle}, nil
+		orgId, workspaceHandle = w.IdentityId, w.Handle
+	case *openapi.Workspace:
+		orgId, workspaceHandle = w.IdentityId, w.Handle
 	default:
 		plugin.Logger(ctx).Debug("getOrgDetails", "Unknown Type", w)
+		orgWorkspaceUser := h.Item.(openapi.OrgWorkspaceUser)
+		orgId, workspaceHandle = orgWorkspaceUser.OrgId, orgWorkspaceUser.WorkspaceHandle
 	}
 
-	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		resp, _, err := svc.Orgs.Get(ctx, h.Item.(openapi.OrgWorkspaceUser).OrgId).Execute()
-		return resp, err
+	identity, err := resolveIdentity(ctx, d, h, orgId)
+	if err != nil {
+		plugin.Logger(ctx).Error("getOrgDetails", "resolveIdentity", err)
+		return nil, err
 	}
-	response, _ := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
 
-	return &OrgWorkspaceDetails{OrgHandle: response.(openapi.Org).Handle, WorkspaceHandle: h.Item.(openapi.OrgWorkspaceUser).WorkspaceHandle}, nil
+	return &OrgWorkspaceDetails{OrgHandle: identity.Handle, WorkspaceHandle: workspaceHandle}, nil
 }
@@ -3,7 +3,6 @@ package steampipecloud
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	openapi "github.com/turbot/steampipe-cloud-sdk-go"
 
@@ -34,13 +33,23 @@ func tableSteampipeCloudProcess(_ context.Context) *plugin.Table {
 					Name:    "identity_id",
 					Require: plugin.Optional,
 				},
+				{
+					Name:      "state",
+					Require:   plugin.Optional,
+					Operators: []string{"=", "<>"},
+				},
+				{
+					Name:      "type",
+					Require:   plugin.Optional,
+					Operators: []string{"=", "<>"},
+				},
 			},
 		},
 		Get: &plugin.GetConfig{
 			KeyColumns: plugin.AllColumns([]string{"identity_handle", "id"}),
 			Hydrate:    getIdentityProcess,
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for the process.",
@@ -81,6 +90,36 @@ func tableSteampipeCloudProcess(_ context.Context) *plugin.Table {
 				Description: "The current state of the process.",
 				Type:        proto.ColumnType_STRING,
 			},
+			{
+				Name:        "started_at",
+				Description: "The time when the process started, which is when it was created, unless it is still queued.",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Hydrate:     getProcessLifecycle,
+			},
+			{
+				Name:        "finished_at",
+				Description: "The time when the process reached a terminal state.",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Hydrate:     getProcessLifecycle,
+			},
+			{
+				Name:        "duration",
+				Description: "How long the process ran for in seconds, or has been running for if it has not finished. Null if the process finished at an unknown time.",
+				Type:        proto.ColumnType_DOUBLE,
+				Hydrate:     getProcessLifecycle,
+			},
+			{
+				Name:        "is_terminal",
+				Description: "True if the process has finished, failed or been cancelled.",
+				Type:        proto.ColumnType_BOOL,
+				Hydrate:     getProcessLifecycle,
+			},
+			{
+				Name:        "outcome",
+				Description: "The outcome of a process which has reached a terminal state, i.e. success, failure or cancelled.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getProcessLifecycle,
+			},
 			{
 				Name:        "created_at",
 				Description: "The time when the process was created.",
@@ -119,37 +158,17 @@ func tableSteampipeCloudProcess(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
 //// LIST FUNCTION
 
 func listIdentityProcesses(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	var user openapi.User
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if limit != nil {
-		if *limit < int64(maxResults) {
-			maxResults = int32(*limit)
-		}
-	}
-
 	identityHandle := d.EqualsQuals["identity_handle"].GetStringValue()
 	identityId := d.EqualsQuals["identity_id"].GetStringValue()
 	var identityToPass string
 
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_process.listIdentityProcesses", "getUserIdentityCached", err)
-		return nil, err
-	}
-
-	user = commonData.(openapi.User)
-
 	// Error out if both identity_handle and identity_id is passed
 	if identityHandle != "" && identityId != "" {
 		return nil, fmt.Errorf("please pass any one of identity_handle or identity_id")
@@ -159,129 +178,37 @@ func listIdentityProcesses(ctx context.Context, d *plugin.QueryData, h *plugin.H
 	} else if identityId != "" {
 		identityToPass = identityId
 	} else {
-		identityToPass = user.Id
-	}
-
-	if strings.HasPrefix(identityToPass, "u_") || identityToPass == user.Handle {
-		err = listUserProcesses(ctx, d, h, identityToPass, maxResults)
-	} else if strings.HasPrefix(identityToPass, "o_") || identityToPass != user.Handle {
-		err = listOrgProcesses(ctx, d, h, identityToPass, maxResults)
+		getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
+		commonData, err := getUserIdentityCached(ctx, d, h)
+		if err != nil {
+			plugin.Logger(ctx).Error("steampipecloud_process.listIdentityProcesses", "getUserIdentityCached", err)
+			return nil, err
+		}
+		identityToPass = commonData.(openapi.User).Id
 	}
 
+	// Create Session
+	client, err := connectIdentity(ctx, d, h, identityToPass)
 	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_process.listIdentityProcesses", "query_error", err)
+		plugin.Logger(ctx).Error("steampipecloud_process.listIdentityProcesses", "connection_error", err)
 		return nil, err
 	}
 
-	return nil, nil
-}
-
-func listUserProcesses(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, userHandle string, maxResults int32) error {
-	// Create Session
-	svc, err := connect(ctx, d)
+	// build the filter from the quals passed, excluding those used to select the identity
+	filter, err := buildQueryFilter(d, "identity_id", "identity_handle")
 	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_process.listUserProcesses", "connection_error", err)
-		return err
-	}
-
-	pagesLeft := true
-	var resp openapi.ListProcessesResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserProcesses.List(ctx, userHandle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserProcesses.List(ctx, userHandle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("steampipecloud_process.listUserProcesses", "query_error", err)
-			return err
-		}
-
-		result := response.(openapi.ListProcessesResponse)
-
-		if result.HasItems() {
-			for _, process := range *result.Items {
-				d.StreamListItem(ctx, process)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+		plugin.Logger(ctx).Error("steampipecloud_process.listIdentityProcesses", "filter_error", err)
+		return nil, err
 	}
 
-	return nil
-}
-
-func listOrgProcesses(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle string, maxResults int32) error {
-	// Create Session
-	svc, err := connect(ctx, d)
+	// execute list call
+	err = paginate(ctx, d, h, client.listProcesses(filter))
 	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_process.listOrgProcesses", "connection_error", err)
-		return err
-	}
-
-	pagesLeft := true
-	var resp openapi.ListProcessesResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgProcesses.List(ctx, orgHandle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgProcesses.List(ctx, orgHandle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("steampipecloud_process.listOrgProcesses", "query_error", err)
-			return err
-		}
-
-		result := response.(openapi.ListProcessesResponse)
-
-		if result.HasItems() {
-			for _, process := range *result.Items {
-				d.StreamListItem(ctx, process)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+		plugin.Logger(ctx).Error("steampipecloud_process.listIdentityProcesses", "query_error", err)
+		return nil, err
 	}
 
-	return nil
+	return nil, nil
 }
 
 func getIdentityProcess(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
@@ -293,108 +220,37 @@ func getIdentityProcess(ctx context.Context, d *plugin.QueryData, h *plugin.Hydr
 		return nil, nil
 	}
 
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_process.getIdentityProcess", "connection_error", err)
-		return nil, err
-	}
-
-	user := commonData.(openapi.User)
-	var response interface{}
-	if identityHandle == user.Handle {
-		response, err = getUserProcess(ctx, d, h, identityHandle, processId)
-	} else {
-		response, err = getOrgProcess(ctx, d, h, identityHandle, processId)
-	}
-
-	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_process.getIdentityProcess", "query_error", err)
-		return nil, err
-	}
-
-	return response.(openapi.SpProcess), nil
-}
-
-func getUserProcess(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, userHandle, processId string) (interface{}, error) {
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, identityHandle)
 	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_process.getUserProcess", "connection_error", err)
-		return nil, err
-	}
-
-	var process openapi.SpProcess
-
-	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		process, _, err = svc.UserProcesses.Get(ctx, userHandle, processId).Execute()
-		return process, err
-	}
-
-	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_process.getUserProcess", "query_error", err)
-		return nil, err
-	}
-
-	return response, nil
-}
-
-func getOrgProcess(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle, processId string) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_process.getOrgProcess", "connection_error", err)
+		plugin.Logger(ctx).Error("steampipecloud_process.getIdentityProcess", "connection_error", err)
 		return nil, err
 	}
 
-	var process openapi.SpProcess
-
 	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		process, _, err = svc.OrgProcesses.Get(ctx, orgHandle, processId).Execute()
-		return process, err
+		return client.getProcess(ctx, processId)
 	}
 
 	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
 	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_process.getOrgProcess", "query_error", err)
+		plugin.Logger(ctx).Error("steampipecloud_process.getIdentityProcess", "query_error", err)
 		return nil, err
 	}
 
-	return response, nil
+	return response.(openapi.SpProcess), nil
 }
 
 func getIdentityDetailsForProcess(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_process.getIdentityDetailsForProcess", "connection_error", err)
-		return nil, err
+	process := h.Item.(openapi.SpProcess)
+	if process.IdentityId == nil {
+		return nil, nil
 	}
 
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
+	identity, err := resolveIdentity(ctx, d, h, *process.IdentityId)
 	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_process.getIdentityDetailsForProcess", "getUserIdentityCached", err)
+		plugin.Logger(ctx).Error("steampipecloud_process.getIdentityDetailsForProcess", "query_error", err)
 		return nil, err
 	}
 
-	user := commonData.(openapi.User)
-
-	var identityDetails IdentityDetailsForProcess
-	process := h.Item.(openapi.SpProcess)
-	plugin.Logger(ctx).Info("getIdentityDetailsForProcess", "process Item", process)
-	if *process.IdentityId == user.Id {
-		identityDetails.IdentityHandle = user.Handle
-		identityDetails.IdentityType = "user"
-	} else {
-		org, _, err := svc.Orgs.Get(ctx, *process.IdentityId).Execute()
-		if err != nil {
-			plugin.Logger(ctx).Error("steampipecloud_process.getIdentityDetailsForProcess", "query_error", err)
-			return nil, err
-		}
-		identityDetails.IdentityHandle = org.Handle
-		identityDetails.IdentityType = "org"
-	}
-	return identityDetails, nil
+	return IdentityDetailsForProcess{IdentityHandle: identity.Handle, IdentityType: identity.Type}, nil
 }
@@ -19,7 +19,7 @@ func tableSteampipeCloudUserEmail(_ context.Context) *plugin.Table {
 		List: &plugin.ListConfig{
 			Hydrate: listUserEmails,
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for the user email.",
@@ -47,7 +47,7 @@ func tableSteampipeCloudUserEmail(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
@@ -59,24 +59,6 @@ func listUserEmails(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateD
 		return nil, err
 	}
 
-	// declare variables
-	pagesLeft := true
-	var resp openapi.ListUserEmailsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < int64(maxResults) {
-			if *limit < 1 {
-				maxResults = int32(1)
-			} else {
-				maxResults = int32(*limit)
-			}
-		}
-	}
-
 	// Get Cached user
 	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity)
 	commonData, err := getUserIdentityCached(ctx, d, h)
@@ -86,43 +68,18 @@ func listUserEmails(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateD
 	}
 	user := commonData.(openapi.User)
 
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.Users.ListEmails(ctx, user.Handle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.Users.ListEmails(ctx, user.Handle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listUserEmails", "list", err)
-			return nil, err
+	err = paginate(ctx, d, h, func(ctx context.Context, nextToken *string, limit int32) ([]openapi.UserEmail, *string, error) {
+		req := svc.Users.ListEmails(ctx, user.Handle).Limit(limit)
+		if nextToken != nil {
+			req = req.NextToken(*nextToken)
 		}
+		resp, _, err := req.Execute()
+		return resp.GetItems(), resp.NextToken, err
+	})
 
-		result := response.(openapi.ListUserEmailsResponse)
-
-		if result.HasItems() {
-			for _, userEmail := range *result.Items {
-				d.StreamListItem(ctx, userEmail)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil, nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+	if err != nil {
+		plugin.Logger(ctx).Error("listUserEmails", "list", err)
+		return nil, err
 	}
 
 	return nil, nil
@@ -2,7 +2,6 @@ package steampipecloud
 
 import (
 	"context"
-	"strings"
 
 	openapi "github.com/turbot/steampipe-cloud-sdk-go"
 
@@ -39,7 +38,7 @@ func tableSteampipeCloudWorkspace(_ context.Context) *plugin.Table {
 			KeyColumns: plugin.AllColumns([]string{"handle", "identity_handle"}),
 			Hydrate:    getWorkspace,
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for the workspace.",
@@ -140,213 +139,153 @@ func tableSteampipeCloudWorkspace(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
 //// LIST FUNCTION
 
 func listWorkspaces(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("listWorkspaces", "connection_error", err)
-		return nil, err
-	}
-
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("listWorkspaces", "getUserIdentityCached", err)
-		return nil, err
-	}
-
-	user := commonData.(openapi.User)
-
 	identityHandle := d.EqualsQuals["identity_handle"].GetStringValue()
 	identityId := d.EqualsQuals["identity_id"].GetStringValue()
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < int64(maxResults) {
-			if *limit < 1 {
-				maxResults = int32(1)
-			} else {
-				maxResults = int32(*limit)
-			}
+	if identityHandle == "" && identityId == "" {
+		// Create Session
+		svc, err := connect(ctx, d)
+		if err != nil {
+			plugin.Logger(ctx).Error("listWorkspaces", "connection_error", err)
+			return nil, err
+		}
+		err = listActorWorkspaces(ctx, d, h, svc)
+		if err != nil {
+			plugin.Logger(ctx).Error("listWorkspaces", "list", err)
+			return nil, err
 		}
+		return nil, nil
 	}
 
-	if identityHandle == "" && identityId == "" {
-		err = listActorWorkspaces(ctx, d, h, svc, maxResults)
-	} else if identityId != "" && strings.HasPrefix(identityId, "u_") {
-		err = listUserWorkspaces(ctx, d, h, identityId, svc, maxResults)
-	} else if identityId != "" && strings.HasPrefix(identityId, "o_") {
-		err = listOrgWorkspaces(ctx, d, h, identityId, svc, maxResults)
-	} else if identityHandle == user.Handle {
-		err = listUserWorkspaces(ctx, d, h, identityHandle, svc, maxResults)
-	} else {
-		err = listOrgWorkspaces(ctx, d, h, identityHandle, svc, maxResults)
+	identity := identityId
+	if identity == "" {
+		identity = identityHandle
 	}
 
+	client, err := connectIdentity(ctx, d, h, identity)
 	if err != nil {
-		plugin.Logger(ctx).Error("listWorkspaces", "list", err)
+		plugin.Logger(ctx).Error("listWorkspaces", "connection_error", err)
 		return nil, err
 	}
-	return nil, nil
-}
 
-func listUserWorkspaces(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, handle string, svc *openapi.APIClient, maxResults int32) error {
-	var err error
+	// If both quals were given they must refer to the same identity
+	if identityHandle != "" && identityHandle != client.identity.Handle {
+		return nil, nil
+	}
 
 	// execute list call
-	pagesLeft := true
-
-	var resp openapi.ListWorkspacesResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaces.List(ctx, handle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaces.List(ctx, handle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
+	err = paginate(ctx, d, h, client.listWorkspaces)
+	if err != nil {
+		plugin.Logger(ctx).Error("listWorkspaces", "list", err)
+		return nil, err
+	}
+	return nil, nil
+}
 
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
+// listQualifiedWorkspaces is the parent hydrate for workspace level tables
+// which take workspace quals. If both the identity and the workspace are
+// given, only that identity's workspaces are read rather than listing every
+// workspace, and a workspace handle is fetched directly;
+// otherwise it lists workspaces as listWorkspaces does, and the child hydrate
+// uses workspaceMatchesQuals to skip workspaces which were not asked for.
+func listQualifiedWorkspaces(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	identity, workspace := workspaceQuals(d)
+	if identity == "" || workspace == "" {
+		return listWorkspaces(ctx, d, h)
+	}
 
-		if err != nil {
-			plugin.Logger(ctx).Error("listUserWorkspaces", "list", err)
-			return err
+	// Create Session
+	client, err := connectIdentity(ctx, d, h, identity)
+	if err != nil {
+		if isAPIError(err, apiErrorNotFound) {
+			return nil, nil
 		}
+		plugin.Logger(ctx).Error("listQualifiedWorkspaces", "connection_error", err)
+		return nil, err
+	}
 
-		result := response.(openapi.ListWorkspacesResponse)
-
-		if result.HasItems() {
-			for _, workspace := range *result.Items {
-				d.StreamListItem(ctx, workspace)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
+	var response interface{}
+	if handle := d.EqualsQuals["workspace_handle"].GetStringValue(); handle != "" {
+		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+			return client.getWorkspace(ctx, handle)
 		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
+		response, err = plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
+	} else {
+		// the API only gets workspaces by handle
+		response, err = getWorkspaceById(ctx, d, h, client, workspace)
+	}
+	if err != nil {
+		// No such workspace, so there are no rows, as when listing workspaces
+		if isAPIError(err, apiErrorNotFound) {
+			return nil, nil
 		}
+		plugin.Logger(ctx).Error("listQualifiedWorkspaces", "get", err)
+		return nil, err
 	}
 
-	return nil
+	d.StreamListItem(ctx, response.(openapi.Workspace))
+	return nil, nil
 }
 
-func listOrgWorkspaces(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, handle string, svc *openapi.APIClient, maxResults int32) error {
-	var err error
-
-	// execute list call
-	pagesLeft := true
-
-	var resp openapi.ListWorkspacesResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaces.List(ctx, handle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaces.List(ctx, handle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listOrgWorkspaces", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListWorkspacesResponse)
-
-		if result.HasItems() {
-			for _, workspace := range *result.Items {
-				d.StreamListItem(ctx, workspace)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+// workspaceQuals returns the identity and workspace given by the quals, as an
+// id or handle. Either is "" if it was not given, in which case the workspace
+// level tables fan out across all the workspaces listed.
+func workspaceQuals(d *plugin.QueryData) (string, string) {
+	identity := d.EqualsQuals["identity_id"].GetStringValue()
+	if identity == "" {
+		identity = d.EqualsQuals["identity_handle"].GetStringValue()
 	}
-
-	return nil
+	workspace := d.EqualsQuals["workspace_id"].GetStringValue()
+	if workspace == "" {
+		workspace = d.EqualsQuals["workspace_handle"].GetStringValue()
+	}
+	return identity, workspace
 }
 
-func listActorWorkspaces(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, svc *openapi.APIClient, maxResults int32) error {
-	var err error
+// workspaceMatchesQuals returns false if the identity or workspace quals
+// passed refer to a different workspace.
+func workspaceMatchesQuals(d *plugin.QueryData, identity *Identity, workspace *openapi.Workspace) bool {
+	if handle := d.EqualsQuals["identity_handle"].GetStringValue(); handle != "" && handle != identity.Handle {
+		return false
+	}
+	if id := d.EqualsQuals["identity_id"].GetStringValue(); id != "" && id != identity.Id {
+		return false
+	}
+	if handle := d.EqualsQuals["workspace_handle"].GetStringValue(); handle != "" && handle != workspace.Handle {
+		return false
+	}
+	if id := d.EqualsQuals["workspace_id"].GetStringValue(); id != "" && id != workspace.Id {
+		return false
+	}
+	return true
+}
 
+func listActorWorkspaces(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, svc *openapi.APIClient) error {
 	// execute list call
-	pagesLeft := true
-
-	var resp openapi.ListActorWorkspacesResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.Actors.ListWorkspaces(ctx).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.Actors.ListWorkspaces(ctx).Limit(maxResults).Execute()
-				return resp, err
-			}
+	err := paginate(ctx, d, h, func(ctx context.Context, nextToken *string, limit int32) ([]*openapi.Workspace, *string, error) {
+		req := svc.Actors.ListWorkspaces(ctx).Limit(limit)
+		if nextToken != nil {
+			req = req.NextToken(*nextToken)
 		}
+		resp, _, err := req.Execute()
 
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listActorWorkspaces", "list", err)
-			return err
+		var workspaces []*openapi.Workspace
+		for _, actorWorkspace := range resp.GetItems() {
+			workspaces = append(workspaces, actorWorkspace.Workspace)
 		}
+		return workspaces, resp.NextToken, err
+	})
 
-		result := response.(openapi.ListActorWorkspacesResponse)
-
-		if result.HasItems() {
-			for _, actorWorkspace := range *result.Items {
-				d.StreamListItem(ctx, actorWorkspace.Workspace)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+	if err != nil {
+		plugin.Logger(ctx).Error("listActorWorkspaces", "list", err)
+		return err
 	}
 
 	return nil
@@ -364,94 +303,27 @@ func getWorkspace(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateDat
 	}
 
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, identityHandle)
 	if err != nil {
 		plugin.Logger(ctx).Error("getWorkspace", "connection_error", err)
 		return nil, err
 	}
 
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("getWorkspace", "getUserIdentityCached", err)
-		return nil, err
-	}
-
-	user := commonData.(openapi.User)
-	var resp interface{}
-
-	if identityHandle == user.Handle {
-		resp, err = getUserWorkspace(ctx, d, h, identityHandle, handle, svc)
-	} else {
-		resp, err = getOrgWorkspace(ctx, d, h, identityHandle, handle, svc)
-	}
-
-	if err != nil {
-		plugin.Logger(ctx).Error("getWorkspace", "get", err)
-		return nil, err
-	}
-
-	if resp == nil {
-		return nil, nil
-	}
-
-	return resp.(openapi.Workspace), nil
-}
-
-func getOrgWorkspace(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, identityHandle string, handle string, svc *openapi.APIClient) (interface{}, error) {
-	var err error
-
-	// execute get call
-	var resp openapi.Workspace
-
-	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		resp, _, err = svc.OrgWorkspaces.Get(ctx, identityHandle, handle).Execute()
-		return resp, err
-	}
-
-	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-	workspace := response.(openapi.Workspace)
-
-	if err != nil {
-		plugin.Logger(ctx).Error("getOrgWorkspace", "get", err)
-		return nil, err
-	}
-
-	return workspace, nil
-}
-
-func getUserWorkspace(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, identityHandle string, handle string, svc *openapi.APIClient) (interface{}, error) {
-	var err error
-
 	// execute get call
-	var resp openapi.Workspace
-
 	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		resp, _, err = svc.UserWorkspaces.Get(ctx, identityHandle, handle).Execute()
-		return resp, err
+		return client.getWorkspace(ctx, handle)
 	}
 
 	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-	workspace := response.(openapi.Workspace)
-
 	if err != nil {
-		plugin.Logger(ctx).Error("getUserWorkspace", "get", err)
+		plugin.Logger(ctx).Error("getWorkspace", "get", err)
 		return nil, err
 	}
 
-	return workspace, nil
+	return response.(openapi.Workspace), nil
 }
 
 func getIdentityDetails(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("getIdentityDetails", "connection_error", err)
-		return nil, err
-	}
-
 	// Get the identity id from the workspace hydrate object
 	var identityId string
 	switch w := h.Item.(type) {
@@ -463,13 +335,11 @@ func getIdentityDetails(ctx context.Context, d *plugin.QueryData, h *plugin.Hydr
 		plugin.Logger(ctx).Debug("getIdentityDetails", "Unknown Type", w)
 	}
 
-	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		resp, _, err := svc.Identities.Get(ctx, identityId).Execute()
-		return resp, err
+	identity, err := resolveIdentity(ctx, d, h, identityId)
+	if err != nil {
+		plugin.Logger(ctx).Error("getIdentityDetails", "resolveIdentity", err)
+		return nil, err
 	}
 
-	response, _ := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-	identity := response.(openapi.Identity)
-
 	return &IdentityDetails{IdentityHandle: identity.Handle, IdentityType: identity.Type}, nil
 }
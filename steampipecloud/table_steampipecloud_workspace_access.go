@@ -0,0 +1,333 @@
+package steampipecloud
+
+import (
+	"context"
+	"sort"
+
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// The ways in which a user can be granted access to a workspace.
+const (
+	// grantPathOwner is the user who owns a user workspace.
+	grantPathOwner = "owner"
+	// grantPathOrgRole is an org role which grants access to all of the org's
+	// workspaces, i.e. an org owner.
+	grantPathOrgRole = "org_role"
+	// grantPathWorkspaceMember is a membership of the workspace itself.
+	grantPathWorkspaceMember = "workspace_member"
+)
+
+// workspaceRoleRank orders workspace roles from least to most privileged, for
+// choosing the effective role of a user with several grants.
+var workspaceRoleRank = map[string]int{
+	"reader":   1,
+	"operator": 2,
+	"owner":    3,
+}
+
+// WorkspaceAccess is the effective access of a user to a workspace.
+type WorkspaceAccess struct {
+	IdentityId      string                 `json:"identity_id"`
+	IdentityHandle  string                 `json:"identity_handle"`
+	IdentityType    string                 `json:"identity_type"`
+	WorkspaceId     string                 `json:"workspace_id"`
+	WorkspaceHandle string                 `json:"workspace_handle"`
+	UserId          string                 `json:"user_id"`
+	UserHandle      string                 `json:"user_handle"`
+	EffectiveRole   string                 `json:"effective_role"`
+	GrantPath       string                 `json:"grant_path"`
+	OrgRole         *string                `json:"org_role"`
+	WorkspaceRole   *string                `json:"workspace_role"`
+	Grants          []WorkspaceAccessGrant `json:"grants"`
+}
+
+// WorkspaceAccessGrant is one of the ways a user has been granted access to
+// a workspace.
+type WorkspaceAccessGrant struct {
+	GrantPath string `json:"grant_path"`
+	Role      string `json:"role"`
+}
+
+//// TABLE DEFINITION
+
+func tableSteampipeCloudWorkspaceAccess(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "steampipecloud_workspace_access",
+		Description: "The effective role of each user with access to a workspace, and the grants it comes from.",
+		DefaultIgnoreConfig: &plugin.IgnoreConfig{
+			ShouldIgnoreErrorFunc: shouldIgnoreOrgErrors,
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listWorkspaceAccess,
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "identity_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "identity_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "user_handle",
+					Require: plugin.Optional,
+				},
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			{
+				Name:        "identity_id",
+				Description: "The unique identifier of the user or organization which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "identity_handle",
+				Description: "The handle of the user or organization which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "identity_type",
+				Description: "The type of identity which owns the workspace, can be org/user.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "workspace_id",
+				Description: "The unique identifier for the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "workspace_handle",
+				Description: "The handle of the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "user_id",
+				Description: "The unique identifier of the user with access to the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "user_handle",
+				Description: "The handle of the user with access to the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "effective_role",
+				Description: "The most privileged workspace role the user has been granted, i.e. owner, operator or reader.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "grant_path",
+				Description: "How the effective role was granted: owner for the owner of a user workspace, org_role for an organization owner or workspace_member for a member of the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "org_role",
+				Description: "The user's role in the organization which owns the workspace, if any.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "workspace_role",
+				Description: "The user's role as a member of the workspace, if any.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "grants",
+				Description: "All of the grants which give the user access to the workspace.",
+				Type:        proto.ColumnType_JSON,
+			},
+		}),
+	}
+}
+
+//// LIST FUNCTION
+
+func listWorkspaceAccess(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	// Create Session
+	svc, err := connect(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("listWorkspaceAccess", "connection_error", err)
+		return nil, err
+	}
+
+	// The identities whose workspaces are listed: the one asked for, otherwise
+	// the authenticated user and each of their orgs
+	var identities []string
+	if identity := d.EqualsQuals["identity_id"].GetStringValue(); identity != "" {
+		identities = []string{identity}
+	} else if identity := d.EqualsQuals["identity_handle"].GetStringValue(); identity != "" {
+		identities = []string{identity}
+	} else {
+		getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
+		commonData, err := getUserIdentityCached(ctx, d, h)
+		if err != nil {
+			plugin.Logger(ctx).Error("listWorkspaceAccess", "getUserIdentityCached", err)
+			return nil, err
+		}
+		identities = append(identities, commonData.(openapi.User).Id)
+
+		err = forEachItem(ctx, d, h, defaultMaxResults, listActorOrgs(svc), func(org *openapi.Org) bool {
+			identities = append(identities, org.Id)
+			return true
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("listWorkspaceAccess", "list_orgs", err)
+			return nil, err
+		}
+	}
+
+	for _, identity := range identities {
+		client, err := connectIdentity(ctx, d, h, identity)
+		if err == nil {
+			err = listIdentityWorkspaceAccess(ctx, d, h, client)
+		}
+		if err != nil {
+			// Skip orgs the token is not permitted to list, if configured to
+			if shouldIgnoreOrgErrors(ctx, d, h, err) {
+				continue
+			}
+			plugin.Logger(ctx).Error("listWorkspaceAccess", "list", err)
+			return nil, err
+		}
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// listIdentityWorkspaceAccess streams the access to each workspace of an
+// identity. The org members are listed once for all of an org's workspaces.
+func listIdentityWorkspaceAccess(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, client *identityClient) error {
+	var workspaces []openapi.Workspace
+	err := forEachItem(ctx, d, h, defaultMaxResults, client.listWorkspaces, func(workspace openapi.Workspace) bool {
+		if workspaceMatchesQuals(d, client.identity, &workspace) {
+			workspaces = append(workspaces, workspace)
+		}
+		return true
+	})
+	if err != nil || len(workspaces) == 0 {
+		return err
+	}
+
+	// A user workspace is only accessible to its owner
+	if client.identity.IsUser() {
+		for _, workspace := range workspaces {
+			access := newWorkspaceAccess(client.identity, workspace, client.identity.Id, client.identity.Handle)
+			access.addGrant(grantPathOwner, "owner")
+			if !streamWorkspaceAccess(ctx, d, access) {
+				return nil
+			}
+		}
+		return nil
+	}
+
+	// Org owners have owner access to all of the org's workspaces
+	var orgMembers []openapi.OrgUser
+	err = forEachItem(ctx, d, h, defaultMaxResults, listOrgMemberPages(client.svc, client.identity.Handle), func(member openapi.OrgUser) bool {
+		if member.Status == "accepted" {
+			orgMembers = append(orgMembers, member)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, workspace := range workspaces {
+		accessByUser := map[string]*WorkspaceAccess{}
+		for _, member := range orgMembers {
+			access := newWorkspaceAccess(client.identity, workspace, member.UserId, member.UserHandle)
+			access.OrgRole = member.Role
+			if member.Role != nil && *member.Role == "owner" {
+				access.addGrant(grantPathOrgRole, "owner")
+			}
+			accessByUser[member.UserId] = access
+		}
+
+		err := forEachItem(ctx, d, h, defaultMaxResults, listOrgWorkspaceMemberPages(client.svc, client.identity.Handle, workspace.Handle), func(member openapi.OrgWorkspaceUser) bool {
+			if member.Status != "accepted" || member.Role == nil {
+				return true
+			}
+			access, ok := accessByUser[member.UserId]
+			if !ok {
+				access = newWorkspaceAccess(client.identity, workspace, member.UserId, member.UserHandle)
+				accessByUser[member.UserId] = access
+			}
+			access.WorkspaceRole = member.Role
+			access.addGrant(grantPathWorkspaceMember, *member.Role)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+
+		// Stream in a stable order, skipping org members with no access
+		var userIds []string
+		for userId, access := range accessByUser {
+			if access.EffectiveRole != "" {
+				userIds = append(userIds, userId)
+			}
+		}
+		sort.Strings(userIds)
+		for _, userId := range userIds {
+			if !streamWorkspaceAccess(ctx, d, accessByUser[userId]) {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+func newWorkspaceAccess(identity *Identity, workspace openapi.Workspace, userId string, userHandle string) *WorkspaceAccess {
+	return &WorkspaceAccess{
+		IdentityId:      identity.Id,
+		IdentityHandle:  identity.Handle,
+		IdentityType:    identity.Type,
+		WorkspaceId:     workspace.Id,
+		WorkspaceHandle: workspace.Handle,
+		UserId:          userId,
+		UserHandle:      userHandle,
+	}
+}
+
+// addGrant records a grant, which becomes the effective role if it is more
+// privileged than those already recorded.
+func (a *WorkspaceAccess) addGrant(grantPath string, role string) {
+	a.Grants = append(a.Grants, WorkspaceAccessGrant{GrantPath: grantPath, Role: role})
+	if a.EffectiveRole == "" || workspaceRoleRank[role] > workspaceRoleRank[a.EffectiveRole] {
+		a.EffectiveRole = role
+		a.GrantPath = grantPath
+	}
+}
+
+// streamWorkspaceAccess streams a row if it matches the user_handle qual, and
+// returns false once no more rows are needed.
+func streamWorkspaceAccess(ctx context.Context, d *plugin.QueryData, access *WorkspaceAccess) bool {
+	if userHandle := d.EqualsQuals["user_handle"].GetStringValue(); userHandle != "" && userHandle != access.UserHandle {
+		return true
+	}
+	d.StreamListItem(ctx, access)
+
+	// Context can be cancelled due to manual cancellation or the limit has been hit
+	return d.RowsRemaining(ctx) != 0
+}
@@ -3,7 +3,6 @@ package steampipecloud
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	openapi "github.com/turbot/steampipe-cloud-sdk-go"
 
@@ -50,7 +49,7 @@ func tableSteampipeCloudWorkspaceAggregator(_ context.Context) *plugin.Table {
 			KeyColumns: plugin.AllColumns([]string{"identity_handle", "workspace_handle", "handle"}),
 			Hydrate:    getWorkspaceAggregator,
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for the aggregator.",
@@ -145,7 +144,7 @@ func tableSteampipeCloudWorkspaceAggregator(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
@@ -163,16 +162,6 @@ func listWorkspaceAggregators(ctx context.Context, d *plugin.QueryData, h *plugi
 		plugin.Logger(ctx).Error("steampipecloud_workspace_aggregator.listWorkspaceAggregators", "unknown response type for workspace list parent hydrate call", w)
 	}
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if limit != nil {
-		if *limit < int64(maxResults) {
-			maxResults = int32(*limit)
-		}
-	}
-
 	workspaceHandle := d.EqualsQualString("workspace_handle")
 	workspaceId := d.EqualsQualString("workspace_id")
 	var workspaceToPass string
@@ -195,13 +184,15 @@ func listWorkspaceAggregators(ctx context.Context, d *plugin.QueryData, h *plugi
 		workspaceToPass = workspace.Id
 	}
 
-	var err error
-	if strings.HasPrefix(workspace.IdentityId, "u_") {
-		err = listUserWorkspaceAggregators(ctx, d, h, workspace.IdentityId, workspaceToPass, maxResults)
-	} else {
-		err = listOrgWorkspaceAggregators(ctx, d, h, workspace.IdentityId, workspaceToPass, maxResults)
+	// Create Session
+	client, err := connectIdentity(ctx, d, h, workspace.IdentityId)
+	if err != nil {
+		plugin.Logger(ctx).Error("steampipecloud_workspace_aggregator.listWorkspaceAggregators", "connection_error", err)
+		return nil, err
 	}
 
+	// execute list call
+	err = paginate(ctx, d, h, client.listWorkspaceAggregators(workspaceToPass))
 	if err != nil {
 		plugin.Logger(ctx).Error("steampipecloud_workspace_aggregator.listWorkspaceAggregators", "query_error", err)
 		return nil, err
@@ -210,114 +201,6 @@ func listWorkspaceAggregators(ctx context.Context, d *plugin.QueryData, h *plugi
 	return nil, nil
 }
 
-func listUserWorkspaceAggregators(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, userHandle string, workspaceHandle string, maxResults int32) error {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_workspace_aggregator.listUserWorkspaceAggregators", "connection_error", err)
-		return err
-	}
-
-	pagesLeft := true
-	var resp openapi.ListWorkspaceAggregatorsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaceAggregators.List(ctx, userHandle, workspaceHandle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaceAggregators.List(ctx, userHandle, workspaceHandle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("steampipecloud_workspace_aggregator.listUserWorkspaceAggregators", "query_error", err)
-			return err
-		}
-
-		result := response.(openapi.ListWorkspaceAggregatorsResponse)
-
-		if result.HasItems() {
-			for _, aggregator := range *result.Items {
-				d.StreamListItem(ctx, aggregator)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
-	}
-
-	return nil
-}
-
-func listOrgWorkspaceAggregators(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle string, workspaceHandle string, maxResults int32) error {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_workspace_aggregator.listOrgWorkspaceAggregators", "connection_error", err)
-		return err
-	}
-
-	pagesLeft := true
-	var resp openapi.ListWorkspaceAggregatorsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaceAggregators.List(ctx, orgHandle, workspaceHandle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaceAggregators.List(ctx, orgHandle, workspaceHandle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("steampipecloud_workspace_aggregator.listOrgWorkspaceAggregators", "query_error", err)
-			return err
-		}
-
-		result := response.(openapi.ListWorkspaceAggregatorsResponse)
-
-		if result.HasItems() {
-			for _, aggregator := range *result.Items {
-				d.StreamListItem(ctx, aggregator)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
-	}
-
-	return nil
-}
-
 func getWorkspaceAggregator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	identityHandle := d.EqualsQualString("identity_handle")
 	workspaceHandle := d.EqualsQualString("workspace_handle")
@@ -328,168 +211,38 @@ func getWorkspaceAggregator(ctx context.Context, d *plugin.QueryData, h *plugin.
 		return nil, nil
 	}
 
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("getWorkspaceAggregator", "getUserIdentityCached", err)
-		return nil, err
-	}
-
-	user := commonData.(openapi.User)
-	var response interface{}
-	if identityHandle == user.Handle {
-		response, err = getUserWorkspaceAggregator(ctx, d, h, identityHandle, workspaceHandle, aggregatorHandle)
-	} else {
-		response, err = getOrgWorkspaceAggregator(ctx, d, h, identityHandle, workspaceHandle, aggregatorHandle)
-	}
-
-	if err != nil {
-		plugin.Logger(ctx).Error("getWorkspaceAggregator", "error", err)
-		return nil, err
-	}
-
-	return response.(openapi.WorkspaceAggregator), nil
-}
-
-func getUserWorkspaceAggregator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, userHandle, workspaceHandle, aggregatorHandle string) (interface{}, error) {
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, identityHandle)
 	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_workspace_aggregator.getUserWorkspaceAggregator", "connection_error", err)
+		plugin.Logger(ctx).Error("getWorkspaceAggregator", "connection_error", err)
 		return nil, err
 	}
 
-	var aggregator openapi.WorkspaceAggregator
-
 	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		aggregator, _, err = svc.UserWorkspaceAggregators.Get(ctx, userHandle, workspaceHandle, aggregatorHandle).Execute()
-		return aggregator, err
+		return client.getWorkspaceAggregator(ctx, workspaceHandle, aggregatorHandle)
 	}
 
 	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
 	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_workspace_aggregator.getUserWorkspaceAggregator", "retry_error", err)
-		return nil, err
-	}
-
-	aggregator = response.(openapi.WorkspaceAggregator)
-
-	return aggregator, nil
-}
-
-func getOrgWorkspaceAggregator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle, workspaceHandle, aggregatorHandle string) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("getOrgWorkspaceAggregator", "connection_error", err)
-		return nil, err
-	}
-
-	var aggregator openapi.WorkspaceAggregator
-
-	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		aggregator, _, err = svc.OrgWorkspaceAggregators.Get(ctx, orgHandle, workspaceHandle, aggregatorHandle).Execute()
-		return aggregator, err
-	}
-
-	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-	if err != nil {
-		plugin.Logger(ctx).Error("getOrgWorkspaceAggregator", "get", err)
+		plugin.Logger(ctx).Error("getWorkspaceAggregator", "error", err)
 		return nil, err
 	}
 
-	aggregator = response.(openapi.WorkspaceAggregator)
-
-	return aggregator, nil
+	return response.(openapi.WorkspaceAggregator), nil
 }
 
 func getIdentityWorkspaceDetailsForAggregator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
+	aggregator := h.Item.(openapi.WorkspaceAggregator)
+
+	identity, workspaceHandle, err := resolveIdentityWorkspace(ctx, d, h, aggregator.IdentityId, aggregator.WorkspaceId)
 	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_workspace_aggregator.getIdentityWorkspaceDetailsForAggregator", "connection_error", err)
+		plugin.Logger(ctx).Error("steampipecloud_workspace_aggregator.getIdentityWorkspaceDetailsForAggregator", "query_error", err)
 		return nil, err
 	}
 
-	var identityWorkspaceDetails IdentityWorkspaceDetailsForAggregator
-	// get workspace details from hydrate data
-	// workspace details reside in the parent item in this case
-	switch w := h.ParentItem.(type) {
-	case openapi.Workspace:
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForAggregator", "openapi.Workspace")
-		identityId := h.ParentItem.(openapi.Workspace).IdentityId
-		identityWorkspaceDetails.WorkspaceHandle = h.ParentItem.(openapi.Workspace).Handle
-		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-			if strings.HasPrefix(identityId, "u_") {
-				resp, _, err := svc.Users.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "user"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			} else {
-				resp, _, err := svc.Orgs.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "org"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			}
-		}
-		_, _ = plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-		return identityWorkspaceDetails, nil
-	case *openapi.Workspace:
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForAggregator", "*openapi.Workspace")
-		identityId := h.ParentItem.(*openapi.Workspace).IdentityId
-		identityWorkspaceDetails.WorkspaceHandle = h.ParentItem.(*openapi.Workspace).Handle
-		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-			if strings.HasPrefix(identityId, "u_") {
-				resp, _, err := svc.Users.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "user"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			} else {
-				resp, _, err := svc.Orgs.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "org"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			}
-		}
-		_, _ = plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForAggregator", "identityWorkspaceDetails", identityWorkspaceDetails)
-		return &identityWorkspaceDetails, nil
-	default:
-		// The default case will come up when the parent items does not exist which happens when a get call is executed instead of a list
-		// In a get call the parent hydrate is not executed and hence the parent item will not exist
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForAggregator", "Unknown Type", w)
-		identityId := h.Item.(openapi.WorkspaceAggregator).IdentityId
-		workspaceId := h.Item.(openapi.WorkspaceAggregator).WorkspaceId
-		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-			if strings.HasPrefix(identityId, "u_") {
-				user, _, err := svc.Users.Get(ctx, identityId).Execute()
-				if err != nil {
-					return nil, err
-				}
-				identityWorkspaceDetails.IdentityType = "user"
-				identityWorkspaceDetails.IdentityHandle = user.Handle
-				workspace, _, err := svc.UserWorkspaces.Get(ctx, identityId, workspaceId).Execute()
-				if err != nil {
-					return nil, err
-				}
-				identityWorkspaceDetails.WorkspaceHandle = workspace.Handle
-			} else {
-				org, _, err := svc.Orgs.Get(ctx, identityId).Execute()
-				if err != nil {
-					return nil, err
-				}
-				identityWorkspaceDetails.IdentityType = "org"
-				identityWorkspaceDetails.IdentityHandle = org.Handle
-				workspace, _, err := svc.UserWorkspaces.Get(ctx, identityId, workspaceId).Execute()
-				if err != nil {
-					return nil, err
-				}
-				identityWorkspaceDetails.WorkspaceHandle = workspace.Handle
-			}
-			return nil, nil
-		}
-		_, _ = plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForAggregator", "identityWorkspaceDetails", identityWorkspaceDetails)
-		return &identityWorkspaceDetails, err
-	}
+	return &IdentityWorkspaceDetailsForAggregator{
+		IdentityHandle:  identity.Handle,
+		IdentityType:    identity.Type,
+		WorkspaceHandle: workspaceHandle,
+	}, nil
 }
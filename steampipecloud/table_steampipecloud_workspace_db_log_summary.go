@@ -0,0 +1,432 @@
+package steampipecloud
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+// DBLogSummary aggregates the db logs of a workspace for one actor and query
+// shape over a time window.
+type DBLogSummary struct {
+	IdentityId        string    `json:"identity_id"`
+	IdentityHandle    string    `json:"identity_handle"`
+	WorkspaceId       string    `json:"workspace_id"`
+	WorkspaceHandle   string    `json:"workspace_handle"`
+	ActorId           string    `json:"actor_id"`
+	ActorHandle       string    `json:"actor_handle"`
+	QueryFingerprint  string    `json:"query_fingerprint"`
+	QueryNormalized   string    `json:"query_normalized"`
+	StatementType     string    `json:"statement_type"`
+	TablesReferenced  []string  `json:"tables_referenced"`
+	QueryCount        int64     `json:"query_count"`
+	TotalDuration     float64   `json:"total_duration"`
+	MinDuration       *float64  `json:"min_duration"`
+	MaxDuration       *float64  `json:"max_duration"`
+	AvgDuration       *float64  `json:"avg_duration"`
+	P50Duration       *float64  `json:"p50_duration"`
+	P95Duration       *float64  `json:"p95_duration"`
+	FirstLogTimestamp string    `json:"first_log_timestamp"`
+	LastLogTimestamp  string    `json:"last_log_timestamp"`
+	WindowStart       time.Time `json:"window_start"`
+	WindowEnd         time.Time `json:"window_end"`
+
+	// durations of the queries with a known duration, in milliseconds
+	durations durationSketch
+}
+
+//// TABLE DEFINITION
+
+func tableSteampipeCloudWorkspaceDBLogSummary(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "steampipecloud_workspace_db_log_summary",
+		Description: "Query counts and durations from the database logs of a workspace, aggregated per actor and query shape over a time window. The database logs do not record whether a query failed, so there are no error counts.",
+		List: &plugin.ListConfig{
+			ParentHydrate: listQualifiedWorkspaces,
+			Hydrate:       listWorkspaceDBLogSummaries,
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:      "window_start",
+					Require:   plugin.Required,
+					Operators: []string{"=", ">", ">="},
+				},
+				{
+					Name:    "window_end",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "identity_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "identity_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:      "actor_handle",
+					Require:   plugin.Optional,
+					Operators: []string{"=", "<>"},
+				},
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			{
+				Name:        "identity_id",
+				Description: "The unique identifier of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "identity_handle",
+				Description: "The handle of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "workspace_id",
+				Description: "The unique identifier of the workspace on which the queries were executed.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "workspace_handle",
+				Description: "The handle of the workspace on which the queries were executed.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "actor_id",
+				Description: "The unique identifier for the user who executed the queries.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "actor_handle",
+				Description: "The handle of the user who executed the queries.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "query_fingerprint",
+				Description: "A hash of the normalized query, which is the same for queries that differ only by their literal values.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "query_normalized",
+				Description: "The query with comments removed, whitespace collapsed and literal values replaced by ?.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "statement_type",
+				Description: "The type of the query's statement, e.g. select.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "tables_referenced",
+				Description: "The tables read or written by the query, e.g. aws_s3_bucket or aws.aws_s3_bucket.",
+				Type:        proto.ColumnType_JSON,
+			},
+			{
+				Name:        "query_count",
+				Description: "The number of queries executed in the window.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "total_duration",
+				Description: "The total duration of the queries in milliseconds(ms).",
+				Type:        proto.ColumnType_DOUBLE,
+			},
+			{
+				Name:        "min_duration",
+				Description: "The duration of the fastest query in milliseconds(ms).",
+				Type:        proto.ColumnType_DOUBLE,
+			},
+			{
+				Name:        "max_duration",
+				Description: "The duration of the slowest query in milliseconds(ms).",
+				Type:        proto.ColumnType_DOUBLE,
+			},
+			{
+				Name:        "avg_duration",
+				Description: "The mean duration of the queries in milliseconds(ms).",
+				Type:        proto.ColumnType_DOUBLE,
+			},
+			{
+				Name:        "p50_duration",
+				Description: "The median duration of the queries in milliseconds(ms), accurate to within 1%.",
+				Type:        proto.ColumnType_DOUBLE,
+			},
+			{
+				Name:        "p95_duration",
+				Description: "The 95th percentile duration of the queries in milliseconds(ms), accurate to within 1%.",
+				Type:        proto.ColumnType_DOUBLE,
+			},
+			{
+				Name:        "first_log_timestamp",
+				Description: "The time when the first query in the window was logged.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "last_log_timestamp",
+				Description: "The time when the last query in the window was logged.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "window_start",
+				Description: "The start of the time window summarized, which must be given in the query, e.g. window_start > now() - interval '1 day'.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "window_end",
+				Description: "The end of the time window summarized, which defaults to the time of the query.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+		}),
+	}
+}
+
+//// LIST FUNCTION
+
+func listWorkspaceDBLogSummaries(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	// Get the workspace object from the parent hydrate
+	workspace := workspaceFromItem(h.Item)
+	if workspace == nil {
+		plugin.Logger(ctx).Debug("listWorkspaceDBLogSummaries", "Unknown Type", h.Item)
+		return nil, nil
+	}
+
+	// Create the connection
+	client, err := connectIdentity(ctx, d, h, workspace.IdentityId)
+	if err != nil {
+		plugin.Logger(ctx).Error("listWorkspaceDBLogSummaries", "connection_error", err)
+		return nil, err
+	}
+
+	// Skip workspaces other than the one asked for
+	if !workspaceMatchesQuals(d, client.identity, workspace) {
+		return nil, nil
+	}
+
+	windowStart, startOperator, startValue := dbLogWindowStart(d)
+	windowEnd := time.Now().UTC()
+	if d.EqualsQuals["window_end"] != nil {
+		windowEnd = d.EqualsQuals["window_end"].GetTimestampValue().AsTime()
+	}
+
+	// Only the logs in the window are fetched
+	var filter queryFilter
+	if err := filter.add("log_timestamp", startOperator, startValue); err != nil {
+		return nil, err
+	}
+	if err := filter.add("log_timestamp", "<", timeFilterValue(windowEnd)); err != nil {
+		return nil, err
+	}
+	if d.Quals["actor_handle"] != nil {
+		for _, qual := range d.Quals["actor_handle"].Quals {
+			if err := filter.add("actor_handle", qual.Operator, qual.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Aggregate every log in the window, since the query limit applies to the
+	// summaries rather than the logs
+	summaries := map[string]*DBLogSummary{}
+	err = forEachItem(ctx, d, h, defaultMaxResults, client.listWorkspaceDBLogs(workspace.Id, filter.String()), func(logRecord openapi.LogRecord) bool {
+		var shape QueryShape
+		if logRecord.Query != nil {
+			shape = analyzeQuery(*logRecord.Query)
+		}
+
+		key := logRecord.ActorId + "/" + shape.Fingerprint
+		summary, ok := summaries[key]
+		if !ok {
+			summary = &DBLogSummary{
+				IdentityId:       client.identity.Id,
+				IdentityHandle:   client.identity.Handle,
+				WorkspaceId:      workspace.Id,
+				WorkspaceHandle:  workspace.Handle,
+				ActorId:          logRecord.ActorId,
+				ActorHandle:      logRecord.ActorHandle,
+				QueryFingerprint: shape.Fingerprint,
+				QueryNormalized:  shape.Normalized,
+				StatementType:    shape.StatementType,
+				TablesReferenced: shape.TablesReferenced,
+				WindowStart:      windowStart,
+				WindowEnd:        windowEnd,
+			}
+			summaries[key] = summary
+		}
+		summary.add(logRecord)
+
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		plugin.Logger(ctx).Error("listWorkspaceDBLogSummaries", "list", err)
+		return nil, err
+	}
+
+	// Stream the most frequent queries first
+	var rows []*DBLogSummary
+	for _, summary := range summaries {
+		summary.finish()
+		rows = append(rows, summary)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].QueryCount != rows[j].QueryCount {
+			return rows[i].QueryCount > rows[j].QueryCount
+		}
+		if rows[i].ActorHandle != rows[j].ActorHandle {
+			return rows[i].ActorHandle < rows[j].ActorHandle
+		}
+		return rows[i].QueryFingerprint < rows[j].QueryFingerprint
+	})
+
+	for _, row := range rows {
+		d.StreamListItem(ctx, row)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
+
+// dbLogWindowStart returns the start of the window given by the window_start
+// quals, along with the operator and value to filter the logs by. A window
+// after a time starts a microsecond later, the precision of a Postgres
+// timestamp, so that the window_start of the rows still matches the qual.
+func dbLogWindowStart(d *plugin.QueryData) (time.Time, string, *proto.QualValue) {
+	var start time.Time
+	operator, value := ">=", (*proto.QualValue)(nil)
+	for _, qual := range d.Quals["window_start"].Quals {
+		t := qual.Value.GetTimestampValue().AsTime()
+		qualOperator := ">="
+		if qual.Operator == ">" {
+			t = t.Add(time.Microsecond)
+			qualOperator = ">"
+		}
+		if value == nil || t.After(start) {
+			start, operator, value = t, qualOperator, qual.Value
+		}
+	}
+	return start, operator, value
+}
+
+// add counts a db log in the summary.
+func (s *DBLogSummary) add(logRecord openapi.LogRecord) {
+	s.QueryCount++
+
+	if logRecord.LogTimestamp != nil {
+		if s.FirstLogTimestamp == "" || *logRecord.LogTimestamp < s.FirstLogTimestamp {
+			s.FirstLogTimestamp = *logRecord.LogTimestamp
+		}
+		if *logRecord.LogTimestamp > s.LastLogTimestamp {
+			s.LastLogTimestamp = *logRecord.LogTimestamp
+		}
+	}
+
+	if logRecord.Duration != nil {
+		duration := float64(*logRecord.Duration)
+		s.TotalDuration += duration
+		if s.MinDuration == nil || duration < *s.MinDuration {
+			s.MinDuration = &duration
+		}
+		if s.MaxDuration == nil || duration > *s.MaxDuration {
+			s.MaxDuration = &duration
+		}
+		s.durations.add(duration)
+	}
+}
+
+// finish calculates the duration statistics once all logs have been added.
+func (s *DBLogSummary) finish() {
+	if s.durations.count == 0 {
+		return
+	}
+
+	avg := s.TotalDuration / float64(s.durations.count)
+	// the percentiles are estimates, which can be no larger than the
+	// slowest query
+	p50 := math.Min(s.durations.percentile(50), *s.MaxDuration)
+	p95 := math.Min(s.durations.percentile(95), *s.MaxDuration)
+
+	s.AvgDuration = &avg
+	s.P50Duration = &p50
+	s.P95Duration = &p95
+}
+
+const (
+	// durationSketchExact is the longest duration, in milliseconds, which a
+	// durationSketch counts exactly.
+	durationSketchExact = 100
+	// durationSketchGrowth is how much larger each bucket above
+	// durationSketchExact is than the one before, which bounds the error of
+	// the percentiles.
+	durationSketchGrowth = 1.01
+)
+
+// durationSketch counts query durations in buckets, so that percentiles can
+// be estimated in memory bounded by the range of the durations rather than
+// the number of queries. Durations up to durationSketchExact are counted
+// exactly, and longer ones in buckets 1% apart, so an int32 duration falls in
+// one of fewer than 2,000 buckets.
+type durationSketch struct {
+	buckets map[int]int64
+	count   int64
+}
+
+func (s *durationSketch) add(duration float64) {
+	if s.buckets == nil {
+		s.buckets = map[int]int64{}
+	}
+	s.buckets[durationBucket(duration)]++
+	s.count++
+}
+
+// percentile returns the nearest-rank percentile p of the durations, as the
+// upper bound of the bucket it falls in.
+func (s *durationSketch) percentile(p float64) float64 {
+	rank := int64(math.Ceil(p / 100 * float64(s.count)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var buckets []int
+	for bucket := range s.buckets {
+		buckets = append(buckets, bucket)
+	}
+	sort.Ints(buckets)
+
+	var seen int64
+	for _, bucket := range buckets {
+		seen += s.buckets[bucket]
+		if seen >= rank {
+			return durationBucketUpperBound(bucket)
+		}
+	}
+	return durationBucketUpperBound(buckets[len(buckets)-1])
+}
+
+func durationBucket(duration float64) int {
+	if duration <= durationSketchExact {
+		return int(math.Ceil(duration))
+	}
+	return durationSketchExact + int(math.Ceil(math.Log(duration/durationSketchExact)/math.Log(durationSketchGrowth)))
+}
+
+func durationBucketUpperBound(bucket int) float64 {
+	if bucket <= durationSketchExact {
+		return float64(bucket)
+	}
+	return durationSketchExact * math.Pow(durationSketchGrowth, float64(bucket-durationSketchExact))
+}
@@ -2,7 +2,6 @@ package steampipecloud
 
 import (
 	"context"
-	"strings"
 
 	openapi "github.com/turbot/steampipe-cloud-sdk-go"
 
@@ -31,7 +30,7 @@ func tableSteampipeCloudWorkspaceMod(_ context.Context) *plugin.Table {
 			KeyColumns: plugin.AllColumns([]string{"identity_id", "workspace_id", "alias"}),
 			Hydrate:    getWorkspaceMod,
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for the workspace mod.",
@@ -139,51 +138,27 @@ func tableSteampipeCloudWorkspaceMod(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
 //// LIST FUNCTION
 
 func listWorkspaceMods(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	workspace := h.Item.(*openapi.Workspace)
+	workspace := workspaceFromItem(h.Item)
+	if workspace == nil {
+		return nil, nil
+	}
 
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, workspace.IdentityId)
 	if err != nil {
 		plugin.Logger(ctx).Error("listWorkspaceMods", "connection_error", err)
 		return nil, err
 	}
 
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("listWorkspaceMods", "getUserIdentityCached", err)
-		return nil, err
-	}
-
-	user := commonData.(openapi.User)
-
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < int64(maxResults) {
-			if *limit < 1 {
-				maxResults = int32(1)
-			} else {
-				maxResults = int32(*limit)
-			}
-		}
-	}
-
-	if workspace.IdentityId == user.Id {
-		err = listUserWorkspaceMods(ctx, d, h, workspace.IdentityId, workspace.Id, svc, maxResults)
-	} else {
-		err = listOrgWorkspaceMods(ctx, d, h, workspace.IdentityId, workspace.Id, svc, maxResults)
-	}
-
+	// execute list call
+	err = paginate(ctx, d, h, client.listWorkspaceMods(workspace.Id))
 	if err != nil {
 		plugin.Logger(ctx).Error("listWorkspaceMods", "list", err)
 		return nil, err
@@ -191,106 +166,6 @@ func listWorkspaceMods(ctx context.Context, d *plugin.QueryData, h *plugin.Hydra
 	return nil, nil
 }
 
-func listUserWorkspaceMods(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, userHandle string, workspaceHandle string, svc *openapi.APIClient, maxResults int32) error {
-	var err error
-
-	// execute list call
-	pagesLeft := true
-	var resp openapi.ListWorkspaceModsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaceMods.List(ctx, userHandle, workspaceHandle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaceMods.List(ctx, userHandle, workspaceHandle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listUserWorkspaceMods", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListWorkspaceModsResponse)
-
-		if result.HasItems() {
-			for _, workspaceMod := range *result.Items {
-				d.StreamListItem(ctx, workspaceMod)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
-	}
-
-	return nil
-}
-
-func listOrgWorkspaceMods(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle string, workspaceHandle string, svc *openapi.APIClient, maxResults int32) error {
-	var err error
-
-	// execute list call
-	pagesLeft := true
-	var resp openapi.ListWorkspaceModsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaceMods.List(ctx, orgHandle, workspaceHandle).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaceMods.List(ctx, orgHandle, workspaceHandle).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listUserWorkspaceMods", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListWorkspaceModsResponse)
-
-		if result.HasItems() {
-			for _, workspaceMod := range *result.Items {
-				d.StreamListItem(ctx, workspaceMod)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
-	}
-
-	return nil
-}
-
 //// GET FUNCTION
 
 func getWorkspaceMod(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
@@ -304,149 +179,38 @@ func getWorkspaceMod(ctx context.Context, d *plugin.QueryData, h *plugin.Hydrate
 	}
 
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, identityId)
 	if err != nil {
 		plugin.Logger(ctx).Error("getWorkspaceMod", "connection_error", err)
 		return nil, err
 	}
 
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("getWorkspaceMod", "getUserIdentityCached", err)
-		return nil, err
-	}
-
-	user := commonData.(openapi.User)
-	var resp interface{}
-
-	if identityId == user.Id {
-		resp, err = getUserWorkspaceMod(ctx, d, h, identityId, workspaceId, alias, svc)
-	} else {
-		resp, err = getOrgWorkspaceMod(ctx, d, h, identityId, workspaceId, alias, svc)
-	}
-
-	if err != nil {
-		plugin.Logger(ctx).Error("getWorkspaceMod", "get", err)
-		return nil, err
-	}
-
-	if resp == nil {
-		return nil, nil
-	}
-
-	return resp.(openapi.WorkspaceMod), nil
-}
-
-func getUserWorkspaceMod(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, identityId string, workspaceId string, alias string, svc *openapi.APIClient) (interface{}, error) {
-	var err error
-
 	// execute get call
-	var resp openapi.WorkspaceMod
-
 	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		resp, _, err = svc.UserWorkspaceMods.Get(ctx, identityId, workspaceId, alias).Execute()
-		return resp, err
+		return client.getWorkspaceMod(ctx, workspaceId, alias)
 	}
 
 	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-	workspaceMod := response.(openapi.WorkspaceMod)
-
 	if err != nil {
-		plugin.Logger(ctx).Error("getUserWorkspaceMod", "get", err)
-		return nil, err
-	}
-
-	return workspaceMod, nil
-}
-
-func getOrgWorkspaceMod(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, identityId string, workspaceId string, alias string, svc *openapi.APIClient) (interface{}, error) {
-	var err error
-
-	// execute get call
-	var resp openapi.WorkspaceMod
-
-	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		resp, _, err = svc.OrgWorkspaceMods.Get(ctx, identityId, workspaceId, alias).Execute()
-		return resp, err
-	}
-
-	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-	workspaceMod := response.(openapi.WorkspaceMod)
-
-	if err != nil {
-		plugin.Logger(ctx).Error("getOrgWorkspaceMod", "get", err)
+		plugin.Logger(ctx).Error("getWorkspaceMod", "get", err)
 		return nil, err
 	}
 
-	return workspaceMod, nil
+	return response.(openapi.WorkspaceMod), nil
 }
 
 func getIdentityWorkspaceDetailsForWorkspaceMod(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
+	workspaceMod := h.Item.(openapi.WorkspaceMod)
+
+	identity, workspaceHandle, err := resolveIdentityWorkspace(ctx, d, h, workspaceMod.IdentityId, workspaceMod.WorkspaceId)
 	if err != nil {
-		plugin.Logger(ctx).Error("getIdentityWorkspaceDetailsForWorkspaceMod", "connection_error", err)
+		plugin.Logger(ctx).Error("getIdentityWorkspaceDetailsForWorkspaceMod", "query_error", err)
 		return nil, err
 	}
 
-	var identityWorkspaceDetails IdentityWorkspaceDetailsForWorkspaceMod
-	// get workspace details from hydrate data
-	// workspace details reside in the parent item in this case
-	switch w := h.ParentItem.(type) {
-	case openapi.Workspace:
-		identityId := h.ParentItem.(openapi.Workspace).IdentityId
-		identityWorkspaceDetails.WorkspaceHandle = h.ParentItem.(openapi.Workspace).Handle
-		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-			if strings.HasPrefix(identityId, "u_") {
-				resp, _, err := svc.Users.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "user"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			} else {
-				resp, _, err := svc.Orgs.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "org"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			}
-		}
-		_, _ = plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-		return identityWorkspaceDetails, nil
-	default:
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForWorkspaceMod", "Unknown Type", w)
-	}
-
-	identityId := h.Item.(openapi.WorkspaceMod).IdentityId
-	workspaceId := h.Item.(openapi.WorkspaceMod).WorkspaceId
-	getIdentityDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		if strings.HasPrefix(identityId, "u_") {
-			resp, _, err := svc.Users.Get(ctx, identityId).Execute()
-			identityWorkspaceDetails.IdentityType = "user"
-			identityWorkspaceDetails.IdentityHandle = resp.Handle
-			return nil, err
-		} else {
-			resp, _, err := svc.Orgs.Get(ctx, identityId).Execute()
-			identityWorkspaceDetails.IdentityType = "org"
-			identityWorkspaceDetails.IdentityHandle = resp.Handle
-			return nil, err
-		}
-	}
-	_, _ = plugin.RetryHydrate(ctx, d, h, getIdentityDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-	getWorkspaceDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		if strings.HasPrefix(identityId, "u_") {
-			resp, _, err := svc.UserWorkspaces.Get(ctx, identityId, workspaceId).Execute()
-			identityWorkspaceDetails.WorkspaceHandle = resp.Handle
-			return nil, err
-		} else {
-			resp, _, err := svc.OrgWorkspaces.Get(ctx, identityId, workspaceId).Execute()
-			identityWorkspaceDetails.WorkspaceHandle = resp.Handle
-			return nil, err
-		}
-	}
-	_, _ = plugin.RetryHydrate(ctx, d, h, getWorkspaceDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-	return identityWorkspaceDetails, nil
+	return IdentityWorkspaceDetailsForWorkspaceMod{
+		IdentityHandle:  identity.Handle,
+		IdentityType:    identity.Type,
+		WorkspaceHandle: workspaceHandle,
+	}, nil
 }
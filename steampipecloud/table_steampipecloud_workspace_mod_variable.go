@@ -3,8 +3,6 @@ package steampipecloud
 import (
 	"context"
 
-	openapi "github.com/turbot/steampipe-cloud-sdk-go"
-
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -28,7 +26,7 @@ func tableSteampipeCloudWorkspaceModVariable(_ context.Context) *plugin.Table {
 				},
 			},
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for the workspace mod variable.",
@@ -121,7 +119,7 @@ func tableSteampipeCloudWorkspaceModVariable(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
@@ -136,151 +134,23 @@ func listWorkspaceModVariables(ctx context.Context, d *plugin.QueryData, h *plug
 		return nil, nil
 	}
 
-	workspace := h.Item.(*openapi.Workspace)
-
-	if workspace.Id != workspaceId {
+	workspace := workspaceFromItem(h.Item)
+	if workspace == nil || workspace.Id != workspaceId {
 		return nil, nil
 	}
 
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, workspace.IdentityId)
 	if err != nil {
 		plugin.Logger(ctx).Error("listWorkspaceModVariables", "connection_error", err)
 		return nil, err
 	}
 
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("listWorkspaceModVariables", "getUserIdentityCached", err)
-		return nil, err
-	}
-
-	user := commonData.(openapi.User)
-
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < int64(maxResults) {
-			if *limit < 1 {
-				maxResults = int32(1)
-			} else {
-				maxResults = int32(*limit)
-			}
-		}
-	}
-
-	if workspace.IdentityId == user.Id {
-		err = listUserWorkspaceModVariables(ctx, d, h, workspace.IdentityId, workspace.Id, modId, svc, maxResults)
-	} else {
-		err = listOrgWorkspaceModVariables(ctx, d, h, workspace.IdentityId, workspace.Id, modId, svc, maxResults)
-	}
-
+	// execute list call
+	err = paginate(ctx, d, h, client.listWorkspaceModVariables(workspace.Id, modId))
 	if err != nil {
 		plugin.Logger(ctx).Error("listWorkspaceModVariables", "list", err)
 		return nil, err
 	}
 	return nil, nil
 }
-
-func listUserWorkspaceModVariables(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, userHandle string, workspaceHandle string, modAlias string, svc *openapi.APIClient, maxResults int32) error {
-	var err error
-
-	// execute list call
-	pagesLeft := true
-	var resp openapi.ListWorkspaceModVariablesResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaceModVariables.List(ctx, userHandle, workspaceHandle, modAlias).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaceModVariables.List(ctx, userHandle, workspaceHandle, modAlias).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listUserWorkspaceModVariables", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListWorkspaceModVariablesResponse)
-
-		if result.HasItems() {
-			for _, workspaceMod := range *result.Items {
-				d.StreamListItem(ctx, workspaceMod)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
-	}
-
-	return nil
-}
-
-func listOrgWorkspaceModVariables(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle string, workspaceHandle string, modAlias string, svc *openapi.APIClient, maxResults int32) error {
-	var err error
-
-	// execute list call
-	pagesLeft := true
-	var resp openapi.ListWorkspaceModVariablesResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaceModVariables.List(ctx, orgHandle, workspaceHandle, modAlias).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaceModVariables.List(ctx, orgHandle, workspaceHandle, modAlias).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listUserWorkspaceModVariables", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListWorkspaceModVariablesResponse)
-
-		if result.HasItems() {
-			for _, workspaceMod := range *result.Items {
-				d.StreamListItem(ctx, workspaceMod)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
-	}
-
-	return nil
-}
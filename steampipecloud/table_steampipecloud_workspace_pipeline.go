@@ -3,7 +3,6 @@ package steampipecloud
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	openapi "github.com/turbot/steampipe-cloud-sdk-go"
@@ -81,7 +80,7 @@ func tableSteampipeCloudWorkspacePipeline(_ context.Context) *plugin.Table {
 			KeyColumns: plugin.AllColumns([]string{"identity_handle", "workspace_handle", "id"}),
 			Hydrate:    getWorkspacePipeline,
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for the pipeline.",
@@ -128,6 +127,36 @@ func tableSteampipeCloudWorkspacePipeline(_ context.Context) *plugin.Table {
 				Description: "The frequency at which the pipeline will be executed.",
 				Type:        proto.ColumnType_JSON,
 			},
+			{
+				Name:        "frequency_type",
+				Description: "The type of the pipeline's frequency, i.e. cron or interval.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getPipelineSchedule,
+			},
+			{
+				Name:        "schedule",
+				Description: "The cron expression on which the pipeline is executed, for a cron pipeline.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getPipelineSchedule,
+			},
+			{
+				Name:        "interval",
+				Description: "The interval at which the pipeline is executed, for an interval pipeline, e.g. hourly, daily, weekly or monthly.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getPipelineSchedule,
+			},
+			{
+				Name:        "next_run_at",
+				Description: "The time when the pipeline is next due to be executed.",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Hydrate:     getPipelineSchedule,
+			},
+			{
+				Name:        "previous_run_at",
+				Description: "The most recent time when the pipeline was due to be executed, calculated from its frequency.",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Hydrate:     getPipelineSchedule,
+			},
 			{
 				Name:        "pipeline",
 				Description: "The name of the pipeline to be executed.",
@@ -198,7 +227,7 @@ func tableSteampipeCloudWorkspacePipeline(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
@@ -216,16 +245,6 @@ func listWorkspacePipelines(ctx context.Context, d *plugin.QueryData, h *plugin.
 		plugin.Logger(ctx).Error("listWorkspacePipelines", "unknown response type for workspace list parent hydrate call", w)
 	}
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if limit != nil {
-		if *limit < int64(maxResults) {
-			maxResults = int32(*limit)
-		}
-	}
-
 	workspaceHandle := d.EqualsQuals["workspace_handle"].GetStringValue()
 	workspaceId := d.EqualsQuals["workspace_id"].GetStringValue()
 	var workspaceToPass string
@@ -248,221 +267,28 @@ func listWorkspacePipelines(ctx context.Context, d *plugin.QueryData, h *plugin.
 		workspaceToPass = workspace.Id
 	}
 
-	var err error
-	if strings.HasPrefix(workspace.IdentityId, "u_") {
-		err = listUserWorkspacePipelines(ctx, d, h, workspace.IdentityId, workspaceToPass, maxResults)
-	} else {
-		err = listOrgWorkspacePipelines(ctx, d, h, workspace.IdentityId, workspaceToPass, maxResults)
-	}
-
+	// Create Session
+	client, err := connectIdentity(ctx, d, h, workspace.IdentityId)
 	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_workspace_pipeline.listWorkspacePipelines", "query_error", err)
+		plugin.Logger(ctx).Error("steampipecloud_workspace_pipeline.listWorkspacePipelines", "connection_error", err)
 		return nil, err
 	}
 
-	return nil, nil
-}
-
-func listUserWorkspacePipelines(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, userHandle string, workspaceHandle string, maxResults int32) error {
-	// Create Session
-	svc, err := connect(ctx, d)
+	// build the filter from the quals passed, excluding those used to select the workspace
+	filter, err := buildQueryFilter(d, "identity_id", "identity_handle", "workspace_id", "workspace_handle")
 	if err != nil {
-		plugin.Logger(ctx).Error("listUserWorkspacePipelines", "connection_error", err)
-		return err
-	}
-
-	var filter string
-	// collect all clauses passed as quals except for "query_where", "identity_id", "identity_handle", "workspace_id", "workspace_handle"
-	var clauses []string
-	for _, keyQual := range d.Table.List.KeyColumns {
-		filterQual := d.Quals[keyQual.Name]
-		if filterQual == nil || keyQual.Name == "query_where" || keyQual.Name == "identity_id" || keyQual.Name == "identity_handle" || keyQual.Name == "workspace_id" || keyQual.Name == "workspace_handle" {
-			continue
-		}
-		for _, qual := range filterQual.Quals {
-			if qual.Value != nil {
-				var value string
-				if keyQual.Name == "created_at" || keyQual.Name == "updated_at" {
-					t := time.Unix(qual.Value.GetTimestampValue().Seconds, int64(qual.Value.GetTimestampValue().Nanos)).UTC()
-					value = t.Format("2006-01-02 15:04:05.00000")
-				} else {
-					value = qual.Value.GetStringValue()
-				}
-				switch qual.Operator {
-				case "=":
-					clauses = append(clauses, fmt.Sprintf(`%s = '%s'`, keyQual.Name, value))
-				case "<>":
-					clauses = append(clauses, fmt.Sprintf(`%s <> '%s'`, keyQual.Name, value))
-				case ">":
-					clauses = append(clauses, fmt.Sprintf(`%s > '%s'`, keyQual.Name, value))
-				case ">=":
-					clauses = append(clauses, fmt.Sprintf(`%s >= '%s'`, keyQual.Name, value))
-				case "<":
-					clauses = append(clauses, fmt.Sprintf(`%s < '%s'`, keyQual.Name, value))
-				case "<=":
-					clauses = append(clauses, fmt.Sprintf(`%s <= '%s'`, keyQual.Name, value))
-				}
-			}
-		}
-	}
-
-	// Frame the filter string by joining the collected quals by "and"
-	filter = strings.Join(clauses, " and ")
-
-	// Check if a query_where qual has been passed and add it to the filter string if yes
-	if d.EqualsQuals["query_where"] != nil {
-		if len(filter) >= 1 {
-			filter = filter + " and " + d.EqualsQuals["query_where"].GetStringValue()
-		} else {
-			filter = d.EqualsQuals["query_where"].GetStringValue()
-		}
-	}
-
-	pagesLeft := true
-	var resp openapi.ListPipelinesResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspacePipelines.List(ctx, userHandle, workspaceHandle).Where(filter).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspacePipelines.List(ctx, userHandle, workspaceHandle).Where(filter).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("steampipecloud_workspace_pipeline.listUserWorkspacePipelines", "query_error", err)
-			return err
-		}
-
-		result := response.(openapi.ListPipelinesResponse)
-
-		if result.HasItems() {
-			for _, pipeline := range *result.Items {
-				d.StreamListItem(ctx, pipeline)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+		plugin.Logger(ctx).Error("steampipecloud_workspace_pipeline.listWorkspacePipelines", "filter_error", err)
+		return nil, err
 	}
 
-	return nil
-}
-
-func listOrgWorkspacePipelines(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle string, workspaceHandle string, maxResults int32) error {
-	// Create Session
-	svc, err := connect(ctx, d)
+	// execute list call
+	err = paginate(ctx, d, h, client.listWorkspacePipelines(workspaceToPass, filter))
 	if err != nil {
-		plugin.Logger(ctx).Error("steampipecloud_workspace_pipeline.listOrgWorkspacePipelines", "connection_error", err)
-		return err
-	}
-
-	var filter string
-	// collect all clauses passed as quals except for "query_where", "identity_id", "identity_handle", "workspace_id", "workspace_handle"
-	var clauses []string
-	for _, keyQual := range d.Table.List.KeyColumns {
-		filterQual := d.Quals[keyQual.Name]
-		if filterQual == nil || keyQual.Name == "query_where" || keyQual.Name == "identity_id" || keyQual.Name == "identity_handle" || keyQual.Name == "workspace_id" || keyQual.Name == "workspace_handle" {
-			continue
-		}
-		for _, qual := range filterQual.Quals {
-			if qual.Value != nil {
-				var value string
-				if keyQual.Name == "created_at" || keyQual.Name == "updated_at" {
-					t := time.Unix(qual.Value.GetTimestampValue().Seconds, int64(qual.Value.GetTimestampValue().Nanos)).UTC()
-					value = t.Format("2006-01-02 15:04:05.00000")
-				} else {
-					value = qual.Value.GetStringValue()
-				}
-				switch qual.Operator {
-				case "=":
-					clauses = append(clauses, fmt.Sprintf(`%s = '%s'`, keyQual.Name, value))
-				case "<>":
-					clauses = append(clauses, fmt.Sprintf(`%s <> '%s'`, keyQual.Name, value))
-				case ">":
-					clauses = append(clauses, fmt.Sprintf(`%s > '%s'`, keyQual.Name, value))
-				case ">=":
-					clauses = append(clauses, fmt.Sprintf(`%s >= '%s'`, keyQual.Name, value))
-				case "<":
-					clauses = append(clauses, fmt.Sprintf(`%s < '%s'`, keyQual.Name, value))
-				case "<=":
-					clauses = append(clauses, fmt.Sprintf(`%s <= '%s'`, keyQual.Name, value))
-				}
-			}
-		}
-	}
-
-	// Frame the filter string by joining the collected quals by "and"
-	filter = strings.Join(clauses, " and ")
-
-	// Check if a query_where qual has been passed and add it to the filter string if yes
-	if d.EqualsQuals["query_where"] != nil {
-		if len(filter) >= 1 {
-			filter = filter + " and " + d.EqualsQuals["query_where"].GetStringValue()
-		} else {
-			filter = d.EqualsQuals["query_where"].GetStringValue()
-		}
-	}
-
-	pagesLeft := true
-	var resp openapi.ListPipelinesResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspacePipelines.List(ctx, orgHandle, workspaceHandle).Where(filter).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspacePipelines.List(ctx, orgHandle, workspaceHandle).Where(filter).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("steampipecloud_workspace_pipeline.listOrgWorkspacePipelines", "query_error", err)
-			return err
-		}
-
-		result := response.(openapi.ListPipelinesResponse)
-
-		if result.HasItems() {
-			for _, pipeline := range *result.Items {
-				d.StreamListItem(ctx, pipeline)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+		plugin.Logger(ctx).Error("steampipecloud_workspace_pipeline.listWorkspacePipelines", "query_error", err)
+		return nil, err
 	}
 
-	return nil
+	return nil, nil
 }
 
 func getWorkspacePipeline(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
@@ -475,163 +301,56 @@ func getWorkspacePipeline(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 		return nil, nil
 	}
 
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("getWorkspacePipeline", "getUserIdentityCached", err)
-		return nil, err
-	}
-
-	user := commonData.(openapi.User)
-	var response interface{}
-	if identityHandle == user.Handle {
-		response, err = getUserWorkspacePipeline(ctx, d, h, identityHandle, workspaceHandle, pipelineId)
-	} else {
-		response, err = getOrgWorkspacePipeline(ctx, d, h, identityHandle, workspaceHandle, pipelineId)
-	}
-
-	if err != nil {
-		plugin.Logger(ctx).Error("getWorkspacePipeline", "error", err)
-		return nil, err
-	}
-
-	return response.(openapi.Pipeline), nil
-}
-
-func getUserWorkspacePipeline(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, userHandle, workspaceHandle, pipelineId string) (interface{}, error) {
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, identityHandle)
 	if err != nil {
-		plugin.Logger(ctx).Error("getUserWorkspacePipeline", "connection_error", err)
+		plugin.Logger(ctx).Error("getWorkspacePipeline", "connection_error", err)
 		return nil, err
 	}
 
-	var pipeline openapi.Pipeline
-
 	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		pipeline, _, err = svc.UserWorkspacePipelines.Get(ctx, userHandle, workspaceHandle, pipelineId).Execute()
-		return pipeline, err
+		return client.getWorkspacePipeline(ctx, workspaceHandle, pipelineId)
 	}
 
 	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
 	if err != nil {
-		plugin.Logger(ctx).Error("getUserWorkspacePipeline", "get", err)
+		plugin.Logger(ctx).Error("getWorkspacePipeline", "error", err)
 		return nil, err
 	}
 
-	return response, nil
+	return response.(openapi.Pipeline), nil
 }
 
-func getOrgWorkspacePipeline(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle, workspaceHandle, pipelineId string) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("getOrgWorkspacePipeline", "connection_error", err)
-		return nil, err
-	}
-
-	var pipeline openapi.Pipeline
+func getIdentityWorkspaceDetailsForPipeline(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	pipeline := h.Item.(openapi.Pipeline)
 
-	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		pipeline, _, err = svc.OrgWorkspacePipelines.Get(ctx, orgHandle, workspaceHandle, pipelineId).Execute()
-		return pipeline, err
+	var workspaceId string
+	if pipeline.WorkspaceId != nil {
+		workspaceId = *pipeline.WorkspaceId
 	}
 
-	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
+	identity, workspaceHandle, err := resolveIdentityWorkspace(ctx, d, h, pipeline.IdentityId, workspaceId)
 	if err != nil {
-		plugin.Logger(ctx).Error("getOrgWorkspacePipeline", "get", err)
+		plugin.Logger(ctx).Error("getIdentityWorkspaceDetailsForPipeline", "query_error", err)
 		return nil, err
 	}
 
-	return response, nil
+	return &IdentityWorkspaceDetailsForPipeline{
+		IdentityHandle:  identity.Handle,
+		IdentityType:    identity.Type,
+		WorkspaceHandle: workspaceHandle,
+	}, nil
 }
 
-func getIdentityWorkspaceDetailsForPipeline(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
+func getPipelineSchedule(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	pipeline := h.Item.(openapi.Pipeline)
+
+	schedule, err := decodePipelineSchedule(pipeline.Frequency.Type, pipeline.Frequency.Schedule, pipeline.NextRunAt, pipeline.CreatedAt, time.Now())
 	if err != nil {
-		plugin.Logger(ctx).Error("getIdentityWorkspaceDetailsForPipeline", "connection_error", err)
-		return nil, err
+		// An unparseable schedule should not fail the query, so it is returned
+		// without its run times
+		plugin.Logger(ctx).Warn("getPipelineSchedule", "decode_error", err, "pipeline_id", pipeline.Id)
 	}
 
-	var identityWorkspaceDetails IdentityWorkspaceDetailsForPipeline
-	// get workspace details from hydrate data
-	// workspace details reside in the parent item in this case
-	switch w := h.ParentItem.(type) {
-	case openapi.Workspace:
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForPipeline", "openapi.Workspace")
-		identityId := h.ParentItem.(openapi.Workspace).IdentityId
-		identityWorkspaceDetails.WorkspaceHandle = h.ParentItem.(openapi.Workspace).Handle
-		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-			if strings.HasPrefix(identityId, "u_") {
-				resp, _, err := svc.Users.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "user"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			} else {
-				resp, _, err := svc.Orgs.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "org"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			}
-		}
-		_, _ = plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-		return identityWorkspaceDetails, nil
-	case *openapi.Workspace:
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForPipeline", "*openapi.Workspace")
-		identityId := h.ParentItem.(*openapi.Workspace).IdentityId
-		identityWorkspaceDetails.WorkspaceHandle = h.ParentItem.(*openapi.Workspace).Handle
-		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-			if strings.HasPrefix(identityId, "u_") {
-				resp, _, err := svc.Users.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "user"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			} else {
-				resp, _, err := svc.Orgs.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "org"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			}
-		}
-		_, _ = plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForPipeline", "identityWorkspaceDetails", identityWorkspaceDetails)
-		return &identityWorkspaceDetails, nil
-	default:
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForPipeline", "Unknown Type", w)
-		// Since the parent id is of unknown type we can assume that its a get operation.
-		identityId := h.Item.(openapi.Pipeline).IdentityId
-		workspaceId := h.Item.(openapi.Pipeline).WorkspaceId
-		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-			if strings.HasPrefix(identityId, "u_") {
-				user, _, err := svc.Users.Get(ctx, identityId).Execute()
-				if err != nil {
-					return nil, err
-				}
-				identityWorkspaceDetails.IdentityType = "user"
-				identityWorkspaceDetails.IdentityHandle = user.Handle
-				workspace, _, err := svc.UserWorkspaces.Get(ctx, identityId, *workspaceId).Execute()
-				if err != nil {
-					return nil, err
-				}
-				identityWorkspaceDetails.WorkspaceHandle = workspace.Handle
-			} else {
-				org, _, err := svc.Orgs.Get(ctx, identityId).Execute()
-				if err != nil {
-					return nil, err
-				}
-				identityWorkspaceDetails.IdentityType = "org"
-				identityWorkspaceDetails.IdentityHandle = org.Handle
-				workspace, _, err := svc.UserWorkspaces.Get(ctx, identityId, *workspaceId).Execute()
-				if err != nil {
-					return nil, err
-				}
-				identityWorkspaceDetails.WorkspaceHandle = workspace.Handle
-			}
-			return nil, nil
-		}
-		_, _ = plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForAggregator", "identityWorkspaceDetails", identityWorkspaceDetails)
-		return &identityWorkspaceDetails, err
-	}
+	return schedule, nil
 }
@@ -0,0 +1,434 @@
+package steampipecloud
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+const (
+	// defaultPipelineHealthWindow is how far back processes are included when
+	// the query does not give a window_start.
+	defaultPipelineHealthWindow = 30 * 24 * time.Hour
+
+	// pipelineOverdueGrace is how long after a scheduled run a pipeline may
+	// take to start its process before it is overdue.
+	pipelineOverdueGrace = 15 * time.Minute
+)
+
+// PipelineHealth summarizes the recent processes of a pipeline.
+type PipelineHealth struct {
+	IdentityId          string     `json:"identity_id"`
+	IdentityHandle      string     `json:"identity_handle"`
+	WorkspaceId         string     `json:"workspace_id"`
+	WorkspaceHandle     string     `json:"workspace_handle"`
+	PipelineId          string     `json:"pipeline_id"`
+	Title               *string    `json:"title"`
+	Pipeline            string     `json:"pipeline"`
+	DesiredState        string     `json:"desired_state"`
+	FrequencyType       string     `json:"frequency_type"`
+	Schedule            *string    `json:"schedule"`
+	Interval            *string    `json:"interval"`
+	PreviousRunAt       *time.Time `json:"previous_run_at"`
+	NextRunAt           *time.Time `json:"next_run_at"`
+	ProcessCount        int64      `json:"process_count"`
+	SuccessCount        int64      `json:"success_count"`
+	FailureCount        int64      `json:"failure_count"`
+	SuccessRate         *float64   `json:"success_rate"`
+	ConsecutiveFailures int64      `json:"consecutive_failures"`
+	MeanDuration        *float64   `json:"mean_duration"`
+	MaxDuration         *float64   `json:"max_duration"`
+	LastRunAt           *time.Time `json:"last_run_at"`
+	LastState           *string    `json:"last_state"`
+	LastSuccessAt       *time.Time `json:"last_success_at"`
+	LastFailureAt       *time.Time `json:"last_failure_at"`
+	IsOverdue           bool       `json:"is_overdue"`
+	WindowStart         time.Time  `json:"window_start"`
+}
+
+//// TABLE DEFINITION
+
+func tableSteampipeCloudWorkspacePipelineHealth(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "steampipecloud_workspace_pipeline_health",
+		Description: "The success rate, run durations and overdue status of each pipeline in a workspace, from its recent processes.",
+		List: &plugin.ListConfig{
+			ParentHydrate: listQualifiedWorkspaces,
+			Hydrate:       listWorkspacePipelineHealth,
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "identity_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "identity_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "pipeline_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "window_start",
+					Require: plugin.Optional,
+				},
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			{
+				Name:        "identity_id",
+				Description: "The unique identifier of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "identity_handle",
+				Description: "The handle of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "workspace_id",
+				Description: "The unique identifier of the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "workspace_handle",
+				Description: "The handle of the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "pipeline_id",
+				Description: "The unique identifier of the pipeline.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "title",
+				Description: "The title of the pipeline.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "pipeline",
+				Description: "The name of the pipeline to be executed.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "desired_state",
+				Description: "The desired state of the pipeline, i.e. enabled or disabled.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "frequency_type",
+				Description: "The type of the pipeline's frequency, i.e. cron or interval.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "schedule",
+				Description: "The cron expression on which the pipeline is executed, for a cron pipeline.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "interval",
+				Description: "The interval at which the pipeline is executed, for an interval pipeline.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "previous_run_at",
+				Description: "The most recent time when the pipeline was due to be executed.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "next_run_at",
+				Description: "The time when the pipeline is next due to be executed.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "process_count",
+				Description: "The number of processes created for the pipeline since window_start.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "success_count",
+				Description: "The number of processes since window_start which finished successfully.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "failure_count",
+				Description: "The number of processes since window_start which failed.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "success_rate",
+				Description: "The percentage of processes since window_start which finished successfully, of those which succeeded or failed.",
+				Type:        proto.ColumnType_DOUBLE,
+			},
+			{
+				Name:        "consecutive_failures",
+				Description: "The number of the most recent processes which failed in a row.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "mean_duration",
+				Description: "The mean run duration of the processes since window_start which have finished, in seconds.",
+				Type:        proto.ColumnType_DOUBLE,
+			},
+			{
+				Name:        "max_duration",
+				Description: "The longest run duration of the processes since window_start which have finished, in seconds.",
+				Type:        proto.ColumnType_DOUBLE,
+			},
+			{
+				Name:        "last_run_at",
+				Description: "The time when the most recent process of the pipeline was created.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "last_state",
+				Description: "The state of the most recent process of the pipeline.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "last_success_at",
+				Description: "The time when the most recent successful process since window_start finished.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "last_failure_at",
+				Description: "The time when the most recent failed process since window_start finished.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "is_overdue",
+				Description: "True if the pipeline is enabled and no process has been created since its most recent scheduled run, allowing 15 minutes for it to start.",
+				Type:        proto.ColumnType_BOOL,
+			},
+			{
+				Name:        "window_start",
+				Description: "The start of the window from which processes are included, which defaults to 30 days ago.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+		}),
+	}
+}
+
+//// LIST FUNCTION
+
+func listWorkspacePipelineHealth(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	// Get the workspace object from the parent hydrate
+	workspace := workspaceFromItem(h.Item)
+	if workspace == nil {
+		plugin.Logger(ctx).Debug("listWorkspacePipelineHealth", "Unknown Type", h.Item)
+		return nil, nil
+	}
+
+	// Create the connection
+	client, err := connectIdentity(ctx, d, h, workspace.IdentityId)
+	if err != nil {
+		plugin.Logger(ctx).Error("listWorkspacePipelineHealth", "connection_error", err)
+		return nil, err
+	}
+
+	// Skip workspaces other than the one asked for
+	if !workspaceMatchesQuals(d, client.identity, workspace) {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	windowStart := now.Add(-defaultPipelineHealthWindow)
+	if d.EqualsQuals["window_start"] != nil {
+		windowStart = d.EqualsQuals["window_start"].GetTimestampValue().AsTime()
+	}
+
+	var pipelineFilter queryFilter
+	if d.EqualsQuals["pipeline_id"] != nil {
+		if err := pipelineFilter.add("id", "=", d.EqualsQuals["pipeline_id"]); err != nil {
+			return nil, err
+		}
+	}
+
+	var pipelines []openapi.Pipeline
+	err = forEachItem(ctx, d, h, defaultMaxResults, client.listWorkspacePipelines(workspace.Handle, pipelineFilter.String()), func(pipeline openapi.Pipeline) bool {
+		pipelines = append(pipelines, pipeline)
+		return true
+	})
+	if err != nil {
+		plugin.Logger(ctx).Error("listWorkspacePipelineHealth", "list_pipelines", err)
+		return nil, err
+	}
+
+	for _, pipeline := range pipelines {
+		health := newPipelineHealth(ctx, client.identity, workspace, pipeline, windowStart, now)
+		if err := health.addProcesses(ctx, d, h, client, workspace.Handle, pipeline); err != nil {
+			plugin.Logger(ctx).Error("listWorkspacePipelineHealth", "list_processes", err)
+			return nil, err
+		}
+		health.finish(pipeline, now)
+
+		d.StreamListItem(ctx, health)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func newPipelineHealth(ctx context.Context, identity *Identity, workspace *openapi.Workspace, pipeline openapi.Pipeline, windowStart time.Time, now time.Time) *PipelineHealth {
+	health := &PipelineHealth{
+		IdentityId:      identity.Id,
+		IdentityHandle:  identity.Handle,
+		WorkspaceId:     workspace.Id,
+		WorkspaceHandle: workspace.Handle,
+		PipelineId:      pipeline.Id,
+		Title:           pipeline.Title,
+		Pipeline:        pipeline.Pipeline,
+		DesiredState:    pipeline.DesiredState,
+		WindowStart:     windowStart,
+	}
+
+	schedule, err := decodePipelineSchedule(pipeline.Frequency.Type, pipeline.Frequency.Schedule, pipeline.NextRunAt, pipeline.CreatedAt, now)
+	if err != nil {
+		// The health of a pipeline with an unparseable schedule is still
+		// reported, it just cannot be overdue
+		plugin.Logger(ctx).Warn("listWorkspacePipelineHealth", "decode_error", err, "pipeline_id", pipeline.Id)
+	}
+	health.FrequencyType = schedule.FrequencyType
+	health.Schedule = schedule.Schedule
+	health.Interval = schedule.Interval
+	health.PreviousRunAt = schedule.PreviousRunAt
+	health.NextRunAt = schedule.NextRunAt
+
+	return health
+}
+
+// addProcesses counts the processes of the pipeline created since the start
+// of the window.
+func (p *PipelineHealth) addProcesses(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, client *identityClient, workspaceHandle string, pipeline openapi.Pipeline) error {
+	var filter queryFilter
+	if err := filter.add("pipeline_id", "=", stringFilterValue(pipeline.Id)); err != nil {
+		return err
+	}
+	if err := filter.add("created_at", ">=", timeFilterValue(p.WindowStart)); err != nil {
+		return err
+	}
+
+	var processes []openapi.SpProcess
+	err := forEachItem(ctx, d, h, defaultMaxResults, client.listWorkspaceProcesses(workspaceHandle, filter.String()), func(process openapi.SpProcess) bool {
+		if process.PipelineId != nil && *process.PipelineId == pipeline.Id {
+			processes = append(processes, process)
+		}
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Work from the most recent process back, for the consecutive failures.
+	// Creation times are compared as times, as they may be given in different
+	// time zones or precisions. Those which cannot be parsed sort last.
+	createdAt := make(map[string]time.Time, len(processes))
+	for _, process := range processes {
+		if t, err := time.Parse(time.RFC3339Nano, process.CreatedAt); err == nil {
+			createdAt[process.Id] = t
+		}
+	}
+	sort.SliceStable(processes, func(i, j int) bool {
+		return createdAt[processes[i].Id].After(createdAt[processes[j].Id])
+	})
+
+	var totalDuration float64
+	var durationCount int
+	countingFailures := true
+	for i, process := range processes {
+		p.ProcessCount++
+		if i == 0 {
+			if t, ok := createdAt[process.Id]; ok {
+				p.LastRunAt = &t
+			}
+			p.LastState = process.State
+		}
+
+		outcome := processOutcome(process)
+		finishedAt := processFinishedAt(process)
+		switch outcome {
+		case processOutcomeSuccess:
+			p.SuccessCount++
+			if p.LastSuccessAt == nil {
+				p.LastSuccessAt = finishedAt
+			}
+			countingFailures = false
+		case processOutcomeFailure:
+			p.FailureCount++
+			if p.LastFailureAt == nil {
+				p.LastFailureAt = finishedAt
+			}
+			if countingFailures {
+				p.ConsecutiveFailures++
+			}
+		case processOutcomeCancelled:
+			countingFailures = false
+		}
+
+		if duration := processDuration(process); duration != nil {
+			seconds := duration.Seconds()
+			totalDuration += seconds
+			durationCount++
+			if p.MaxDuration == nil || seconds > *p.MaxDuration {
+				p.MaxDuration = &seconds
+			}
+		}
+	}
+
+	if durationCount > 0 {
+		mean := totalDuration / float64(durationCount)
+		p.MeanDuration = &mean
+	}
+	if completed := p.SuccessCount + p.FailureCount; completed > 0 {
+		rate := float64(p.SuccessCount) / float64(completed) * 100
+		p.SuccessRate = &rate
+	}
+
+	return nil
+}
+
+// finish works out whether the pipeline is overdue, using its last process
+// if no process was created in the window. A pipeline is overdue if no
+// process has been created since the last run it was due to start, allowing
+// for the grace period, unless that run was due before it was created.
+func (p *PipelineHealth) finish(pipeline openapi.Pipeline, now time.Time) {
+	if p.LastRunAt == nil && pipeline.LastProcess != nil {
+		if createdAt, err := time.Parse(time.RFC3339, pipeline.LastProcess.CreatedAt); err == nil {
+			p.LastRunAt = &createdAt
+			p.LastState = pipeline.LastProcess.State
+		}
+	}
+
+	if p.DesiredState != "enabled" {
+		return
+	}
+	due, err := decodePipelineSchedule(pipeline.Frequency.Type, pipeline.Frequency.Schedule, pipeline.NextRunAt, pipeline.CreatedAt, now.Add(-pipelineOverdueGrace))
+	if err != nil || due.PreviousRunAt == nil {
+		return
+	}
+	if createdAt, err := time.Parse(time.RFC3339, pipeline.CreatedAt); err == nil && !due.PreviousRunAt.After(createdAt) {
+		return
+	}
+	p.IsOverdue = p.LastRunAt == nil || p.LastRunAt.Before(*due.PreviousRunAt)
+}
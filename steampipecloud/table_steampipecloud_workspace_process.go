@@ -3,8 +3,6 @@ package steampipecloud
 import (
 	"context"
 	"fmt"
-	"strings"
-	"time"
 
 	openapi "github.com/turbot/steampipe-cloud-sdk-go"
 
@@ -86,7 +84,7 @@ func tableSteampipeCloudWorkspaceProcess(_ context.Context) *plugin.Table {
 			KeyColumns: plugin.AllColumns([]string{"identity_handle", "workspace_handle", "id"}),
 			Hydrate:    getWorkspaceProcess,
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for the process.",
@@ -139,6 +137,36 @@ func tableSteampipeCloudWorkspaceProcess(_ context.Context) *plugin.Table {
 				Description: "The current state of the process.",
 				Type:        proto.ColumnType_STRING,
 			},
+			{
+				Name:        "started_at",
+				Description: "The time when the process started, which is when it was created, unless it is still queued.",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Hydrate:     getProcessLifecycle,
+			},
+			{
+				Name:        "finished_at",
+				Description: "The time when the process reached a terminal state.",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Hydrate:     getProcessLifecycle,
+			},
+			{
+				Name:        "duration",
+				Description: "How long the process ran for in seconds, or has been running for if it has not finished. Null if the process finished at an unknown time.",
+				Type:        proto.ColumnType_DOUBLE,
+				Hydrate:     getProcessLifecycle,
+			},
+			{
+				Name:        "is_terminal",
+				Description: "True if the process has finished, failed or been cancelled.",
+				Type:        proto.ColumnType_BOOL,
+				Hydrate:     getProcessLifecycle,
+			},
+			{
+				Name:        "outcome",
+				Description: "The outcome of a process which has reached a terminal state, i.e. success, failure or cancelled.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getProcessLifecycle,
+			},
 			{
 				Name:        "query_where",
 				Description: "The query where expression to filter workspace processes.",
@@ -183,7 +211,7 @@ func tableSteampipeCloudWorkspaceProcess(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
@@ -201,16 +229,6 @@ func listWorkspaceProcesses(ctx context.Context, d *plugin.QueryData, h *plugin.
 		plugin.Logger(ctx).Error("listWorkspaceProcesses", "unknown response type for workspace list parent hydrate call", w)
 	}
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if limit != nil {
-		if *limit < int64(maxResults) {
-			maxResults = int32(*limit)
-		}
-	}
-
 	workspaceHandle := d.EqualsQuals["workspace_handle"].GetStringValue()
 	workspaceId := d.EqualsQuals["workspace_id"].GetStringValue()
 	var workspaceToPass string
@@ -234,221 +252,28 @@ func listWorkspaceProcesses(ctx context.Context, d *plugin.QueryData, h *plugin.
 		workspaceToPass = workspace.Id
 	}
 
-	var err error
-	if strings.HasPrefix(workspace.IdentityId, "u_") {
-		err = listUserWorkspaceProcesses(ctx, d, h, workspace.IdentityId, workspaceToPass, maxResults)
-	} else {
-		err = listOrgWorkspaceProcesses(ctx, d, h, workspace.IdentityId, workspaceToPass, maxResults)
-	}
-
+	// Create Session
+	client, err := connectIdentity(ctx, d, h, workspace.IdentityId)
 	if err != nil {
-		plugin.Logger(ctx).Error("listWorkspaceProcesses", "error", err)
+		plugin.Logger(ctx).Error("listWorkspaceProcesses", "connection_error", err)
 		return nil, err
 	}
 
-	return nil, nil
-}
-
-func listUserWorkspaceProcesses(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, userHandle, workspaceHandle string, maxResults int32) error {
-	// Create Session
-	svc, err := connect(ctx, d)
+	// build the filter from the quals passed, excluding those used to select the workspace
+	filter, err := buildQueryFilter(d, "identity_id", "identity_handle", "workspace_id", "workspace_handle")
 	if err != nil {
-		plugin.Logger(ctx).Error("listUserWorkspaceProcesses", "connection_error", err)
-		return err
-	}
-
-	var filter string
-	// collect all clauses passed as quals except for "query_where", "identity_id", "identity_handle", "workspace_id", "workspace_handle"
-	var clauses []string
-	for _, keyQual := range d.Table.List.KeyColumns {
-		filterQual := d.Quals[keyQual.Name]
-		if filterQual == nil || keyQual.Name == "query_where" || keyQual.Name == "identity_id" || keyQual.Name == "identity_handle" || keyQual.Name == "workspace_id" || keyQual.Name == "workspace_handle" {
-			continue
-		}
-		for _, qual := range filterQual.Quals {
-			if qual.Value != nil {
-				var value string
-				if keyQual.Name == "created_at" || keyQual.Name == "updated_at" {
-					t := time.Unix(qual.Value.GetTimestampValue().Seconds, int64(qual.Value.GetTimestampValue().Nanos)).UTC()
-					value = t.Format("2006-01-02 15:04:05.00000")
-				} else {
-					value = qual.Value.GetStringValue()
-				}
-				switch qual.Operator {
-				case "=":
-					clauses = append(clauses, fmt.Sprintf(`%s = '%s'`, keyQual.Name, value))
-				case "<>":
-					clauses = append(clauses, fmt.Sprintf(`%s <> '%s'`, keyQual.Name, value))
-				case ">":
-					clauses = append(clauses, fmt.Sprintf(`%s > '%s'`, keyQual.Name, value))
-				case ">=":
-					clauses = append(clauses, fmt.Sprintf(`%s >= '%s'`, keyQual.Name, value))
-				case "<":
-					clauses = append(clauses, fmt.Sprintf(`%s < '%s'`, keyQual.Name, value))
-				case "<=":
-					clauses = append(clauses, fmt.Sprintf(`%s <= '%s'`, keyQual.Name, value))
-				}
-			}
-		}
-	}
-
-	// Frame the filter string by joining the collected quals by "and"
-	filter = strings.Join(clauses, " and ")
-
-	// Check if a query_where qual has been passed and add it to the filter string if yes
-	if d.EqualsQuals["query_where"] != nil {
-		if len(filter) >= 1 {
-			filter = filter + " and " + d.EqualsQuals["query_where"].GetStringValue()
-		} else {
-			filter = d.EqualsQuals["query_where"].GetStringValue()
-		}
-	}
-
-	pagesLeft := true
-	var resp openapi.ListProcessesResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaceProcesses.List(ctx, userHandle, workspaceHandle).Where(filter).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaceProcesses.List(ctx, userHandle, workspaceHandle).Where(filter).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listUserWorkspaceProcesses", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListProcessesResponse)
-
-		if result.HasItems() {
-			for _, process := range *result.Items {
-				d.StreamListItem(ctx, process)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+		plugin.Logger(ctx).Error("listWorkspaceProcesses", "filter_error", err)
+		return nil, err
 	}
 
-	return nil
-}
-
-func listOrgWorkspaceProcesses(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle, workspaceHandle string, maxResults int32) error {
-	// Create Session
-	svc, err := connect(ctx, d)
+	// execute list call
+	err = paginate(ctx, d, h, client.listWorkspaceProcesses(workspaceToPass, filter))
 	if err != nil {
-		plugin.Logger(ctx).Error("listOrgWorkspaceProcesses", "connection_error", err)
-		return err
-	}
-
-	var filter string
-	// collect all clauses passed as quals except for "query_where", "identity_id", "identity_handle", "workspace_id", "workspace_handle"
-	var clauses []string
-	for _, keyQual := range d.Table.List.KeyColumns {
-		filterQual := d.Quals[keyQual.Name]
-		if filterQual == nil || keyQual.Name == "query_where" || keyQual.Name == "identity_id" || keyQual.Name == "identity_handle" || keyQual.Name == "workspace_id" || keyQual.Name == "workspace_handle" {
-			continue
-		}
-		for _, qual := range filterQual.Quals {
-			if qual.Value != nil {
-				var value string
-				if keyQual.Name == "created_at" || keyQual.Name == "updated_at" {
-					t := time.Unix(qual.Value.GetTimestampValue().Seconds, int64(qual.Value.GetTimestampValue().Nanos)).UTC()
-					value = t.Format("2006-01-02 15:04:05.00000")
-				} else {
-					value = qual.Value.GetStringValue()
-				}
-				switch qual.Operator {
-				case "=":
-					clauses = append(clauses, fmt.Sprintf(`%s = '%s'`, keyQual.Name, value))
-				case "<>":
-					clauses = append(clauses, fmt.Sprintf(`%s <> '%s'`, keyQual.Name, value))
-				case ">":
-					clauses = append(clauses, fmt.Sprintf(`%s > '%s'`, keyQual.Name, value))
-				case ">=":
-					clauses = append(clauses, fmt.Sprintf(`%s >= '%s'`, keyQual.Name, value))
-				case "<":
-					clauses = append(clauses, fmt.Sprintf(`%s < '%s'`, keyQual.Name, value))
-				case "<=":
-					clauses = append(clauses, fmt.Sprintf(`%s <= '%s'`, keyQual.Name, value))
-				}
-			}
-		}
-	}
-
-	// Frame the filter string by joining the collected quals by "and"
-	filter = strings.Join(clauses, " and ")
-
-	// Check if a query_where qual has been passed and add it to the filter string if yes
-	if d.EqualsQuals["query_where"] != nil {
-		if len(filter) >= 1 {
-			filter = filter + " and " + d.EqualsQuals["query_where"].GetStringValue()
-		} else {
-			filter = d.EqualsQuals["query_where"].GetStringValue()
-		}
-	}
-
-	pagesLeft := true
-	var resp openapi.ListProcessesResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaceProcesses.List(ctx, orgHandle, workspaceHandle).Where(filter).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaceProcesses.List(ctx, orgHandle, workspaceHandle).Where(filter).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listOrgWorkspaceProcesses", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListProcessesResponse)
-
-		if result.HasItems() {
-			for _, process := range *result.Items {
-				d.StreamListItem(ctx, process)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+		plugin.Logger(ctx).Error("listWorkspaceProcesses", "error", err)
+		return nil, err
 	}
 
-	return nil
+	return nil, nil
 }
 
 func getWorkspaceProcess(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
@@ -461,130 +286,46 @@ func getWorkspaceProcess(ctx context.Context, d *plugin.QueryData, h *plugin.Hyd
 		return nil, nil
 	}
 
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
-	if err != nil {
-		plugin.Logger(ctx).Error("getWorkspaceProcess", "getUserIdentityCached", err)
-		return nil, err
-	}
-
-	user := commonData.(openapi.User)
-	var response interface{}
-	if identityHandle == user.Handle {
-		response, err = getUserWorkspaceProcess(ctx, d, h, identityHandle, workspaceHandle, processId)
-	} else {
-		response, err = getOrgWorkspaceProcess(ctx, d, h, identityHandle, workspaceHandle, processId)
-	}
-
-	if err != nil {
-		plugin.Logger(ctx).Error("getWorkspaceProcess", "error", err)
-		return nil, err
-	}
-
-	return response.(openapi.SpProcess), nil
-}
-
-func getUserWorkspaceProcess(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, userHandle, workspaceHandle, processId string) (interface{}, error) {
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, identityHandle)
 	if err != nil {
-		plugin.Logger(ctx).Error("getUserWorkspaceProcess", "connection_error", err)
+		plugin.Logger(ctx).Error("getWorkspaceProcess", "connection_error", err)
 		return nil, err
 	}
 
-	var process openapi.SpProcess
-
 	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		process, _, err = svc.UserWorkspaceProcesses.Get(ctx, userHandle, workspaceHandle, processId).Execute()
-		return process, err
+		return client.getWorkspaceProcess(ctx, workspaceHandle, processId)
 	}
 
 	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
 	if err != nil {
-		plugin.Logger(ctx).Error("getUserWorkspaceProcess", "get", err)
+		plugin.Logger(ctx).Error("getWorkspaceProcess", "error", err)
 		return nil, err
 	}
 
-	return response, nil
+	return response.(openapi.SpProcess), nil
 }
 
-func getOrgWorkspaceProcess(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle, workspaceHandle, processId string) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("getOrgWorkspaceProcess", "connection_error", err)
-		return nil, err
-	}
-
-	var process openapi.SpProcess
-
-	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		process, _, err = svc.OrgWorkspaceProcesses.Get(ctx, orgHandle, workspaceHandle, processId).Execute()
-		return process, err
+func getIdentityWorkspaceDetailsForWorkspaceProcess(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	process := h.Item.(openapi.SpProcess)
+	if process.IdentityId == nil {
+		return nil, nil
 	}
 
-	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-	if err != nil {
-		plugin.Logger(ctx).Error("getOrgWorkspaceProcess", "get", err)
-		return nil, err
+	var workspaceId string
+	if process.WorkspaceId != nil {
+		workspaceId = *process.WorkspaceId
 	}
 
-	return response, nil
-}
-
-func getIdentityWorkspaceDetailsForWorkspaceProcess(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
+	identity, workspaceHandle, err := resolveIdentityWorkspace(ctx, d, h, *process.IdentityId, workspaceId)
 	if err != nil {
-		plugin.Logger(ctx).Error("getIdentityWorkspaceDetailsForWorkspaceProcess", "connection_error", err)
+		plugin.Logger(ctx).Error("getIdentityWorkspaceDetailsForWorkspaceProcess", "query_error", err)
 		return nil, err
 	}
 
-	var identityWorkspaceDetails IdentityWorkspaceDetailsForProcess
-	// get workspace details from hydrate data
-	// workspace details reside in the parent item in this case
-	switch w := h.ParentItem.(type) {
-	case openapi.Workspace:
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForWorkspaceProcess", "openapi.Workspace")
-		identityId := h.ParentItem.(openapi.Workspace).IdentityId
-		identityWorkspaceDetails.WorkspaceHandle = h.ParentItem.(openapi.Workspace).Handle
-		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-			if strings.HasPrefix(identityId, "u_") {
-				resp, _, err := svc.Users.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "user"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			} else {
-				resp, _, err := svc.Orgs.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "org"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			}
-		}
-		_, _ = plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-		return identityWorkspaceDetails, nil
-	case *openapi.Workspace:
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForWorkspaceProcess", "*openapi.Workspace")
-		identityId := h.ParentItem.(*openapi.Workspace).IdentityId
-		identityWorkspaceDetails.WorkspaceHandle = h.ParentItem.(*openapi.Workspace).Handle
-		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-			if strings.HasPrefix(identityId, "u_") {
-				resp, _, err := svc.Users.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "user"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			} else {
-				resp, _, err := svc.Orgs.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "org"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			}
-		}
-		_, _ = plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForWorkspaceProcess", "identityWorkspaceDetails", identityWorkspaceDetails)
-		return &identityWorkspaceDetails, nil
-	default:
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetailsForWorkspaceProcess", "Unknown Type", w)
-	}
-	return &identityWorkspaceDetails, nil
+	return &IdentityWorkspaceDetailsForProcess{
+		IdentityHandle:  identity.Handle,
+		IdentityType:    identity.Type,
+		WorkspaceHandle: workspaceHandle,
+	}, nil
 }
@@ -0,0 +1,253 @@
+package steampipecloud
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+const (
+	// processLogFile and processLogContentType select the log of a process,
+	// which is returned as JSON lines.
+	processLogFile        = "process"
+	processLogContentType = "jsonl"
+
+	// processLogMaxLineSize is the longest log line which can be read.
+	processLogMaxLineSize = 1024 * 1024
+)
+
+// The fields of a log event which hold its timestamp, level and message, in
+// order of preference. Events from different parts of a process do not all
+// use the same names.
+var (
+	processLogTimestampFields = []string{"timestamp", "time", "ts", "created_at"}
+	processLogLevelFields     = []string{"level", "severity", "lvl"}
+	processLogMessageFields   = []string{"message", "msg", "text"}
+)
+
+// ProcessLogLine is a single line of the log of a process.
+type ProcessLogLine struct {
+	IdentityId      string                 `json:"identity_id"`
+	IdentityHandle  string                 `json:"identity_handle"`
+	WorkspaceId     string                 `json:"workspace_id"`
+	WorkspaceHandle string                 `json:"workspace_handle"`
+	ProcessId       string                 `json:"process_id"`
+	LineNumber      int64                  `json:"line_number"`
+	Timestamp       *time.Time             `json:"timestamp"`
+	Level           *string                `json:"level"`
+	Message         string                 `json:"message"`
+	Data            map[string]interface{} `json:"data"`
+}
+
+//// TABLE DEFINITION
+
+func tableSteampipeCloudWorkspaceProcessLog(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "steampipecloud_workspace_process_log",
+		Description: "The log lines of a process in a workspace, e.g. the output of a pipeline run.",
+		List: &plugin.ListConfig{
+			ParentHydrate: listQualifiedWorkspaces,
+			Hydrate:       listWorkspaceProcessLogs,
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "process_id",
+					Require: plugin.Required,
+				},
+				{
+					Name:    "identity_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "identity_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_id",
+					Require: plugin.Optional,
+				},
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			{
+				Name:        "identity_id",
+				Description: "The unique identifier of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "identity_handle",
+				Description: "The handle of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "workspace_id",
+				Description: "The unique identifier of the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "workspace_handle",
+				Description: "The handle of the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "process_id",
+				Description: "The unique identifier of the process, which must be given in the query.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "line_number",
+				Description: "The position of the line in the log, starting from 1.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "timestamp",
+				Description: "The time when the line was logged.",
+				Type:        proto.ColumnType_TIMESTAMP,
+			},
+			{
+				Name:        "level",
+				Description: "The level of the line, e.g. info or error.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "message",
+				Description: "The message logged, or the whole line if it is not a JSON event.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "data",
+				Description: "The event logged, including any fields other than its timestamp, level and message.",
+				Type:        proto.ColumnType_JSON,
+			},
+		}),
+	}
+}
+
+//// LIST FUNCTION
+
+func listWorkspaceProcessLogs(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	// Get the workspace object from the parent hydrate
+	workspace := workspaceFromItem(h.Item)
+	if workspace == nil {
+		plugin.Logger(ctx).Debug("listWorkspaceProcessLogs", "Unknown Type", h.Item)
+		return nil, nil
+	}
+
+	// Create the connection
+	client, err := connectIdentity(ctx, d, h, workspace.IdentityId)
+	if err != nil {
+		plugin.Logger(ctx).Error("listWorkspaceProcessLogs", "connection_error", err)
+		return nil, err
+	}
+
+	// Skip workspaces other than the one asked for
+	if !workspaceMatchesQuals(d, client.identity, workspace) {
+		return nil, nil
+	}
+
+	processId := d.EqualsQuals["process_id"].GetStringValue()
+	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+		return client.openWorkspaceProcessLog(ctx, workspace.Handle, processId)
+	}
+
+	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
+	if err != nil {
+		// The process belongs to a different workspace
+		if isAPIError(err, apiErrorNotFound) {
+			return nil, nil
+		}
+		plugin.Logger(ctx).Error("listWorkspaceProcessLogs", "query_error", err)
+		return nil, err
+	}
+
+	// The log is streamed line by line, so a long log is not read into
+	// memory and stops being read once the limit has been hit
+	resp := response.(*http.Response)
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), processLogMaxLineSize)
+	var lineNumber int64
+	for scanner.Scan() {
+		lineNumber++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		line := parseProcessLogLine(text)
+		line.IdentityId = client.identity.Id
+		line.IdentityHandle = client.identity.Handle
+		line.WorkspaceId = workspace.Id
+		line.WorkspaceHandle = workspace.Handle
+		line.ProcessId = processId
+		line.LineNumber = lineNumber
+		d.StreamListItem(ctx, line)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		plugin.Logger(ctx).Error("listWorkspaceProcessLogs", "scan_error", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// parseProcessLogLine parses a JSON event from the log of a process. A line
+// which is not a JSON object is returned as the message.
+func parseProcessLogLine(text string) *ProcessLogLine {
+	line := &ProcessLogLine{}
+
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &event); err != nil {
+		line.Message = text
+		return line
+	}
+	line.Data = event
+
+	if value, ok := firstStringField(event, processLogTimestampFields); ok {
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999"} {
+			if t, err := time.Parse(layout, value); err == nil {
+				line.Timestamp = &t
+				break
+			}
+		}
+	}
+	if value, ok := firstStringField(event, processLogLevelFields); ok {
+		level := strings.ToLower(value)
+		line.Level = &level
+	}
+	if value, ok := firstStringField(event, processLogMessageFields); ok {
+		line.Message = value
+	} else {
+		line.Message = text
+	}
+
+	return line
+}
+
+func firstStringField(event map[string]interface{}, names []string) (string, bool) {
+	for _, name := range names {
+		if value, ok := event[name].(string); ok && value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -3,9 +3,6 @@ package steampipecloud
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"strings"
-	"time"
 
 	openapi "github.com/turbot/steampipe-cloud-sdk-go"
 
@@ -20,8 +17,12 @@ type IdentityWorkspaceDetails struct {
 	WorkspaceHandle string `json:"workspace_handle"`
 }
 
+// SnapshotData is the downloaded payload of a snapshot. Data is the payload
+// encoded as a JSON string, as the data column has always returned it, and
+// Payload is the same payload as a JSON object.
 type SnapshotData struct {
-	Data string `json:"data"`
+	Data    string                         `json:"data"`
+	Payload *openapi.WorkspaceSnapshotData `json:"payload"`
 }
 
 //// TABLE DEFINITION
@@ -64,13 +65,22 @@ func tableSteampipeCloudWorkspaceSnapshot(_ context.Context) *plugin.Table {
 					Require:    plugin.Optional,
 					CacheMatch: "exact",
 				},
+				{
+					Name:       "format",
+					Require:    plugin.Optional,
+					CacheMatch: "exact",
+				},
 			},
 		},
 		Get: &plugin.GetConfig{
-			KeyColumns: plugin.AllColumns([]string{"identity_handle", "workspace_handle", "id"}),
-			Hydrate:    getWorkspaceSnapshot,
+			KeyColumns: append(plugin.AllColumns([]string{"identity_handle", "workspace_handle", "id"}), &plugin.KeyColumn{
+				Name:       "format",
+				Require:    plugin.Optional,
+				CacheMatch: "exact",
+			}),
+			Hydrate: getWorkspaceSnapshot,
 		},
-		Columns: []*plugin.Column{
+		Columns: commonColumns([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The unique identifier for the snapshot.",
@@ -149,6 +159,37 @@ func tableSteampipeCloudWorkspaceSnapshot(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_JSON,
 				Hydrate:     getSnapshotData,
 			},
+			{
+				Name:        "payload",
+				Description: "The data for the snapshot as a JSON object, which unlike data can be queried with JSON operators directly.",
+				Type:        proto.ColumnType_JSON,
+				Hydrate:     getSnapshotData,
+			},
+			{
+				Name:        "data_size_bytes",
+				Description: "The size of the data for the snapshot in bytes, which is found without downloading it.",
+				Type:        proto.ColumnType_INT,
+				Hydrate:     getSnapshotDataSize,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "format",
+				Description: "The format of data_text, i.e. json, csv, html or md. Defaults to json.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromQual("format").Transform(defaultSnapshotFormat),
+			},
+			{
+				Name:        "data_text",
+				Description: "The snapshot downloaded in the given format, e.g. a csv or html report for archiving.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getSnapshotDataText,
+			},
+			{
+				Name:        "content_type",
+				Description: "The content type of data_text, e.g. text/csv.",
+				Type:        proto.ColumnType_STRING,
+				Hydrate:     getSnapshotDataText,
+			},
 			{
 				Name:        "query_where",
 				Description: "The query where expression to filter snapshots.",
@@ -198,7 +239,7 @@ func tableSteampipeCloudWorkspaceSnapshot(_ context.Context) *plugin.Table {
 				Type:        proto.ColumnType_INT,
 				Transform:   transform.FromCamel(),
 			},
-		},
+		}),
 	}
 }
 
@@ -216,235 +257,34 @@ func listWorkspaceSnapshots(ctx context.Context, d *plugin.QueryData, h *plugin.
 		plugin.Logger(ctx).Error("listWorkspaceSnapshots", "unknown response type for workspace list parent hydrate call", w)
 	}
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	maxResults := int32(100)
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < int64(maxResults) {
-			if *limit < 1 {
-				maxResults = int32(1)
-			} else {
-				maxResults = int32(*limit)
-			}
-		}
-	}
-
-	var err error
-	if strings.HasPrefix(workspace.IdentityId, "u_") {
-		err = listUserWorkspaceSnapshots(ctx, d, h, workspace.IdentityId, workspace.Handle, maxResults)
-	} else {
-		err = listOrgWorkspaceSnapshots(ctx, d, h, workspace.IdentityId, workspace.Handle, maxResults)
-	}
-
-	if err != nil {
-		plugin.Logger(ctx).Error("listWorkspaceSnapshots", "error", err)
-		return nil, err
-	}
-
-	return nil, nil
-}
-
-func listUserWorkspaceSnapshots(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, userHandle string, workspaceHandle string, maxResults int32) error {
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, workspace.IdentityId)
 	if err != nil {
-		plugin.Logger(ctx).Error("listUserWorkspaceSnapshots", "connection_error", err)
-		return err
-	}
-
-	var filter string
-	// collect all clauses passed as quals except for "query_where"
-	var clauses []string
-	for _, keyQual := range d.Table.List.KeyColumns {
-		filterQual := d.Quals[keyQual.Name]
-		if filterQual == nil || keyQual.Name == "query_where" {
-			continue
-		}
-		for _, qual := range filterQual.Quals {
-			if qual.Value != nil {
-				var value string
-				if keyQual.Name == "created_at" {
-					t := time.Unix(qual.Value.GetTimestampValue().Seconds, int64(qual.Value.GetTimestampValue().Nanos)).UTC()
-					value = t.Format("2006-01-02 15:04:05.00000")
-				} else {
-					value = qual.Value.GetStringValue()
-				}
-				switch qual.Operator {
-				case "=":
-					clauses = append(clauses, fmt.Sprintf(`%s = '%s'`, keyQual.Name, value))
-				case "<>":
-					clauses = append(clauses, fmt.Sprintf(`%s <> '%s'`, keyQual.Name, value))
-				case ">":
-					clauses = append(clauses, fmt.Sprintf(`%s > '%s'`, keyQual.Name, value))
-				case ">=":
-					clauses = append(clauses, fmt.Sprintf(`%s >= '%s'`, keyQual.Name, value))
-				case "<":
-					clauses = append(clauses, fmt.Sprintf(`%s < '%s'`, keyQual.Name, value))
-				case "<=":
-					clauses = append(clauses, fmt.Sprintf(`%s <= '%s'`, keyQual.Name, value))
-				}
-			}
-		}
-	}
-
-	// Frame the filter string by joining the collected quals by "and"
-	filter = strings.Join(clauses, " and ")
-
-	// Check if a query_where qual has been passed and add it to the filter string if yes
-	if d.EqualsQuals["query_where"] != nil {
-		if len(filter) >= 1 {
-			filter = filter + " and " + d.EqualsQuals["query_where"].GetStringValue()
-		} else {
-			filter = d.EqualsQuals["query_where"].GetStringValue()
-		}
+		plugin.Logger(ctx).Error("listWorkspaceSnapshots", "connection_error", err)
+		return nil, err
 	}
 
-	pagesLeft := true
-	var resp openapi.ListWorkspaceSnapshotsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaceSnapshots.List(ctx, userHandle, workspaceHandle).Where(filter).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.UserWorkspaceSnapshots.List(ctx, userHandle, workspaceHandle).Where(filter).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listUserWorkspaceSnapshots", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListWorkspaceSnapshotsResponse)
-
-		if result.HasItems() {
-			for _, snapshot := range *result.Items {
-				d.StreamListItem(ctx, snapshot)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+	// the format only applies to the downloaded data, so check it before listing
+	if _, err := snapshotFormat(d); err != nil {
+		plugin.Logger(ctx).Error("listWorkspaceSnapshots", "format_error", err)
+		return nil, err
 	}
 
-	return nil
-}
-
-func listOrgWorkspaceSnapshots(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle string, workspaceHandle string, maxResults int32) error {
-	// Create Session
-	svc, err := connect(ctx, d)
+	// build the filter from the quals passed
+	filter, err := buildQueryFilter(d, "format")
 	if err != nil {
-		plugin.Logger(ctx).Error("listOrgWorkspaceSnapshots", "connection_error", err)
-		return err
-	}
-
-	var filter string
-	// collect all clauses passed as quals except for "query_where"
-	var clauses []string
-	for _, keyQual := range d.Table.List.KeyColumns {
-		filterQual := d.Quals[keyQual.Name]
-		if filterQual == nil || keyQual.Name == "query_where" {
-			continue
-		}
-		for _, qual := range filterQual.Quals {
-			if qual.Value != nil {
-				var value string
-				if keyQual.Name == "created_at" {
-					t := time.Unix(qual.Value.GetTimestampValue().Seconds, int64(qual.Value.GetTimestampValue().Nanos)).UTC()
-					value = t.Format("2006-01-02 15:04:05.00000")
-				} else {
-					value = qual.Value.GetStringValue()
-				}
-				switch qual.Operator {
-				case "=":
-					clauses = append(clauses, fmt.Sprintf(`%s = '%s'`, keyQual.Name, value))
-				case "<>":
-					clauses = append(clauses, fmt.Sprintf(`%s <> '%s'`, keyQual.Name, value))
-				case ">":
-					clauses = append(clauses, fmt.Sprintf(`%s > '%s'`, keyQual.Name, value))
-				case ">=":
-					clauses = append(clauses, fmt.Sprintf(`%s >= '%s'`, keyQual.Name, value))
-				case "<":
-					clauses = append(clauses, fmt.Sprintf(`%s < '%s'`, keyQual.Name, value))
-				case "<=":
-					clauses = append(clauses, fmt.Sprintf(`%s <= '%s'`, keyQual.Name, value))
-				}
-			}
-		}
-	}
-
-	// Frame the filter string by joining the collected quals by "and"
-	filter = strings.Join(clauses, " and ")
-
-	// Check if a query_where qual has been passed and add it to the filter string if yes
-	if d.EqualsQuals["query_where"] != nil {
-		if len(filter) >= 1 {
-			filter = filter + " and " + d.EqualsQuals["query_where"].GetStringValue()
-		} else {
-			filter = d.EqualsQuals["query_where"].GetStringValue()
-		}
+		plugin.Logger(ctx).Error("listWorkspaceSnapshots", "filter_error", err)
+		return nil, err
 	}
 
-	pagesLeft := true
-	var resp openapi.ListWorkspaceSnapshotsResponse
-	var listDetails func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error)
-
-	for pagesLeft {
-		if resp.NextToken != nil {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaceSnapshots.List(ctx, orgHandle, workspaceHandle).Where(filter).NextToken(*resp.NextToken).Limit(maxResults).Execute()
-				return resp, err
-			}
-		} else {
-			listDetails = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-				resp, _, err = svc.OrgWorkspaceSnapshots.List(ctx, orgHandle, workspaceHandle).Where(filter).Limit(maxResults).Execute()
-				return resp, err
-			}
-		}
-
-		response, err := plugin.RetryHydrate(ctx, d, h, listDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-		if err != nil {
-			plugin.Logger(ctx).Error("listOrgWorkspaceSnapshots", "list", err)
-			return err
-		}
-
-		result := response.(openapi.ListWorkspaceSnapshotsResponse)
-
-		if result.HasItems() {
-			for _, snapshot := range *result.Items {
-				d.StreamListItem(ctx, snapshot)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		if result.NextToken == nil {
-			pagesLeft = false
-		} else {
-			resp.NextToken = result.NextToken
-		}
+	// execute list call
+	err = paginate(ctx, d, h, client.listWorkspaceSnapshots(workspace.Handle, filter))
+	if err != nil {
+		plugin.Logger(ctx).Error("listWorkspaceSnapshots", "error", err)
+		return nil, err
 	}
 
-	return nil
+	return nil, nil
 }
 
 func getWorkspaceSnapshot(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
@@ -457,21 +297,18 @@ func getWorkspaceSnapshot(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 		return nil, nil
 	}
 
-	getUserIdentityCached := plugin.HydrateFunc(getUserIdentity).WithCache()
-	commonData, err := getUserIdentityCached(ctx, d, h)
+	// Create Session
+	client, err := connectIdentity(ctx, d, h, identityHandle)
 	if err != nil {
-		plugin.Logger(ctx).Error("getWorkspaceSnapshot", "getUserIdentityCached", err)
+		plugin.Logger(ctx).Error("getWorkspaceSnapshot", "connection_error", err)
 		return nil, err
 	}
 
-	user := commonData.(openapi.User)
-	var response interface{}
-	if identityHandle == user.Handle {
-		response, err = getUserWorkspaceSnapshot(ctx, d, h, identityHandle, workspaceHandle, snapshotId)
-	} else {
-		response, err = getOrgWorkspaceSnapshot(ctx, d, h, identityHandle, workspaceHandle, snapshotId)
+	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+		return client.getWorkspaceSnapshot(ctx, workspaceHandle, snapshotId)
 	}
 
+	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
 	if err != nil {
 		plugin.Logger(ctx).Error("getWorkspaceSnapshot", "error", err)
 		return nil, err
@@ -480,150 +317,97 @@ func getWorkspaceSnapshot(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 	return response.(openapi.WorkspaceSnapshot), nil
 }
 
-func getUserWorkspaceSnapshot(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, userHandle, workspaceHandle, snapshotId string) (interface{}, error) {
-	// Create Session
-	svc, err := connect(ctx, d)
-	if err != nil {
-		plugin.Logger(ctx).Error("getUserWorkspaceSnapshot", "connection_error", err)
-		return nil, err
-	}
-
-	var snapshot openapi.WorkspaceSnapshot
-
-	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		snapshot, _, err = svc.UserWorkspaceSnapshots.Get(ctx, userHandle, workspaceHandle, snapshotId).Execute()
-		return snapshot, err
-	}
+func getIdentityWorkspaceDetails(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	workspaceSnapshot := h.Item.(openapi.WorkspaceSnapshot)
 
-	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
+	identity, workspaceHandle, err := resolveIdentityWorkspace(ctx, d, h, workspaceSnapshot.IdentityId, workspaceSnapshot.WorkspaceId)
 	if err != nil {
-		plugin.Logger(ctx).Error("getUserWorkspaceSnapshot", "get", err)
+		plugin.Logger(ctx).Error("getIdentityWorkspaceDetails", "query_error", err)
 		return nil, err
 	}
 
-	return response, nil
+	return IdentityWorkspaceDetails{
+		IdentityHandle:  identity.Handle,
+		IdentityType:    identity.Type,
+		WorkspaceHandle: workspaceHandle,
+	}, nil
 }
 
-func getOrgWorkspaceSnapshot(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, orgHandle, workspaceHandle, snapshotId string) (interface{}, error) {
+func getSnapshotData(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	workspaceSnapshot := h.Item.(openapi.WorkspaceSnapshot)
+
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, workspaceSnapshot.IdentityId)
 	if err != nil {
-		plugin.Logger(ctx).Error("getOrgWorkspaceSnapshot", "connection_error", err)
+		plugin.Logger(ctx).Error("getSnapshotData", "connection_error", err)
 		return nil, err
 	}
 
-	var snapshot openapi.WorkspaceSnapshot
-
-	getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		snapshot, _, err = svc.OrgWorkspaceSnapshots.Get(ctx, orgHandle, workspaceHandle, snapshotId).Execute()
-		return snapshot, err
+	data, err := getSnapshotPayload(ctx, d, h, client, workspaceSnapshot.WorkspaceId, workspaceSnapshot)
+	if err != nil {
+		plugin.Logger(ctx).Error("getSnapshotData", "download_error", err)
+		return nil, err
 	}
 
-	response, err := plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
+	// data has always been the payload encoded as a JSON string
+	encoded, err := json.Marshal(data)
 	if err != nil {
-		plugin.Logger(ctx).Error("getOrgWorkspaceSnapshot", "get", err)
+		plugin.Logger(ctx).Error("getSnapshotData", "encode_error", err)
 		return nil, err
 	}
 
-	return response, nil
+	return SnapshotData{Data: string(encoded), Payload: data}, nil
 }
 
-func getIdentityWorkspaceDetails(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+func getSnapshotDataSize(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	workspaceSnapshot := h.Item.(openapi.WorkspaceSnapshot)
+
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, workspaceSnapshot.IdentityId)
 	if err != nil {
-		plugin.Logger(ctx).Error("getIdentityWorkspaceDetails", "connection_error", err)
+		plugin.Logger(ctx).Error("getSnapshotDataSize", "connection_error", err)
 		return nil, err
 	}
 
-	var identityWorkspaceDetails IdentityWorkspaceDetails
-	// get workspace details from hydrate data
-	// workspace details reside in the parent item in this case
-	switch w := h.ParentItem.(type) {
-	case openapi.Workspace:
-		identityId := h.ParentItem.(openapi.Workspace).IdentityId
-		identityWorkspaceDetails.WorkspaceHandle = h.ParentItem.(openapi.Workspace).Handle
-		getDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-			if strings.HasPrefix(identityId, "u_") {
-				resp, _, err := svc.Users.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "user"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			} else {
-				resp, _, err := svc.Orgs.Get(ctx, identityId).Execute()
-				identityWorkspaceDetails.IdentityType = "org"
-				identityWorkspaceDetails.IdentityHandle = resp.Handle
-				return nil, err
-			}
-		}
-		_, _ = plugin.RetryHydrate(ctx, d, h, getDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-		return identityWorkspaceDetails, nil
-	default:
-		plugin.Logger(ctx).Debug("getIdentityWorkspaceDetails", "Unknown Type", w)
-	}
-
-	identityId := h.Item.(openapi.WorkspaceSnapshot).IdentityId
-	workspaceId := h.Item.(openapi.WorkspaceSnapshot).WorkspaceId
-	getIdentityDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		if strings.HasPrefix(identityId, "u_") {
-			resp, _, err := svc.Users.Get(ctx, identityId).Execute()
-			identityWorkspaceDetails.IdentityType = "user"
-			identityWorkspaceDetails.IdentityHandle = resp.Handle
-			return nil, err
-		} else {
-			resp, _, err := svc.Orgs.Get(ctx, identityId).Execute()
-			identityWorkspaceDetails.IdentityType = "org"
-			identityWorkspaceDetails.IdentityHandle = resp.Handle
-			return nil, err
-		}
-	}
-	_, _ = plugin.RetryHydrate(ctx, d, h, getIdentityDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
-
-	getWorkspaceDetails := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		if strings.HasPrefix(identityId, "u_") {
-			resp, _, err := svc.UserWorkspaces.Get(ctx, identityId, workspaceId).Execute()
-			identityWorkspaceDetails.WorkspaceHandle = resp.Handle
-			return nil, err
-		} else {
-			resp, _, err := svc.OrgWorkspaces.Get(ctx, identityId, workspaceId).Execute()
-			identityWorkspaceDetails.WorkspaceHandle = resp.Handle
-			return nil, err
-		}
+	size, err := getSnapshotSize(ctx, d, h, client, workspaceSnapshot.WorkspaceId, workspaceSnapshot)
+	if err != nil {
+		plugin.Logger(ctx).Error("getSnapshotDataSize", "query_error", err)
+		return nil, err
 	}
-	_, _ = plugin.RetryHydrate(ctx, d, h, getWorkspaceDetails, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
 
-	return identityWorkspaceDetails, nil
+	return size, nil
 }
 
-func getSnapshotData(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+func getSnapshotDataText(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	workspaceSnapshot := h.Item.(openapi.WorkspaceSnapshot)
+
+	format, err := snapshotFormat(d)
+	if err != nil {
+		plugin.Logger(ctx).Error("getSnapshotDataText", "format_error", err)
+		return nil, err
+	}
+
 	// Create Session
-	svc, err := connect(ctx, d)
+	client, err := connectIdentity(ctx, d, h, workspaceSnapshot.IdentityId)
 	if err != nil {
-		plugin.Logger(ctx).Error("getSnapshotData", "connection_error", err)
+		plugin.Logger(ctx).Error("getSnapshotDataText", "connection_error", err)
 		return nil, err
 	}
 
-	var snapshotData SnapshotData
-	workspaceSnapshot := h.Item.(openapi.WorkspaceSnapshot)
-	var response openapi.WorkspaceSnapshotData
-	getSnapshotData := func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-		if strings.HasPrefix(workspaceSnapshot.IdentityId, "u_") {
-			response, _, err = svc.UserWorkspaceSnapshots.Download(ctx, workspaceSnapshot.IdentityId, workspaceSnapshot.WorkspaceId, workspaceSnapshot.Id, "json").Execute()
-			if err != nil {
-				return nil, err
-			}
-
-		} else {
-			response, _, err = svc.OrgWorkspaceSnapshots.Download(ctx, workspaceSnapshot.IdentityId, workspaceSnapshot.WorkspaceId, workspaceSnapshot.Id, "json").Execute()
-			if err != nil {
-				return nil, err
-			}
-		}
-		byteArr, _ := json.Marshal(response)
-		snapshotData.Data = string(byteArr)
-		return nil, nil
+	export, err := getSnapshotExport(ctx, d, h, client, workspaceSnapshot.WorkspaceId, workspaceSnapshot, format)
+	if err != nil {
+		plugin.Logger(ctx).Error("getSnapshotDataText", "download_error", err)
+		return nil, err
 	}
-	_, err = plugin.RetryHydrate(ctx, d, h, getSnapshotData, &plugin.RetryConfig{ShouldRetryError: shouldRetryError})
 
-	return snapshotData, nil
+	return export, nil
+}
+
+//// TRANSFORM FUNCTIONS
+
+func defaultSnapshotFormat(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	if d.Value == nil {
+		return snapshotFormatJSON, nil
+	}
+	return d.Value, nil
 }
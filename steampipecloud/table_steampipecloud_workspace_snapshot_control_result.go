@@ -0,0 +1,164 @@
+package steampipecloud
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableSteampipeCloudWorkspaceSnapshotControlResult(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "steampipecloud_workspace_snapshot_control_result",
+		Description: "The control results of a benchmark snapshot, with one row per control and resource.",
+		List: &plugin.ListConfig{
+			ParentHydrate: listQualifiedWorkspaces,
+			Hydrate:       listWorkspaceSnapshotControlResults,
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "snapshot_id",
+					Require: plugin.Required,
+				},
+				{
+					Name:    "identity_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "identity_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "control_name",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "status",
+					Require: plugin.Optional,
+				},
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			{
+				Name:        "identity_id",
+				Description: "The unique identifier of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "identity_handle",
+				Description: "The handle of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "workspace_id",
+				Description: "The unique identifier of the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "workspace_handle",
+				Description: "The handle of the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "snapshot_id",
+				Description: "The unique identifier of the snapshot, which must be given in the query.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "benchmark_name",
+				Description: "The name of the benchmark which the control belongs to.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "benchmark_path",
+				Description: "The names of the benchmarks above the control, from the top of the benchmark tree.",
+				Type:        proto.ColumnType_JSON,
+			},
+			{
+				Name:        "control_name",
+				Description: "The name of the control, e.g. aws_compliance.control.s3_bucket_versioning_enabled.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "control_title",
+				Description: "The title of the control.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "severity",
+				Description: "The severity of the control, e.g. low, medium, high or critical.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "tags",
+				Description: "The tags of the control.",
+				Type:        proto.ColumnType_JSON,
+			},
+			{
+				Name:        "resource",
+				Description: "The resource which the control was run against.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "status",
+				Description: "The status of the control for the resource, i.e. ok, alarm, info, skip or error.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "reason",
+				Description: "The reason for the status of the control for the resource.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "dimensions",
+				Description: "The other columns of the result, such as its dimensions, e.g. the account and region of the resource.",
+				Type:        proto.ColumnType_JSON,
+			},
+		}),
+	}
+}
+
+//// LIST FUNCTION
+
+func listWorkspaceSnapshotControlResults(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	content, err := getQualifiedSnapshotContent(ctx, d, h)
+	if err != nil {
+		plugin.Logger(ctx).Error("listWorkspaceSnapshotControlResults", "download_error", err)
+		return nil, err
+	}
+	if content == nil {
+		return nil, nil
+	}
+
+	controlName := d.EqualsQuals["control_name"].GetStringValue()
+	status := d.EqualsQuals["status"].GetStringValue()
+	for _, result := range content.controlResults() {
+		if controlName != "" && result.ControlName != controlName {
+			continue
+		}
+		if status != "" && (result.Status == nil || *result.Status != status) {
+			continue
+		}
+		d.StreamListItem(ctx, result)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
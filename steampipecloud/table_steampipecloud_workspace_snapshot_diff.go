@@ -0,0 +1,402 @@
+package steampipecloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// The kinds of item compared between snapshots.
+const (
+	snapshotDiffPanelRow      = "panel_row"
+	snapshotDiffControlResult = "control_result"
+)
+
+// The changes to an item between snapshots.
+const (
+	snapshotDiffAdded   = "added"
+	snapshotDiffRemoved = "removed"
+	snapshotDiffChanged = "changed"
+)
+
+// SnapshotDiff is an item which was added, removed or changed between two
+// snapshots of a dashboard.
+type SnapshotDiff struct {
+	IdentityId      string                 `json:"identity_id"`
+	IdentityHandle  string                 `json:"identity_handle"`
+	WorkspaceId     string                 `json:"workspace_id"`
+	WorkspaceHandle string                 `json:"workspace_handle"`
+	FromSnapshotId  string                 `json:"from_snapshot_id"`
+	ToSnapshotId    string                 `json:"to_snapshot_id"`
+	DashboardName   string                 `json:"dashboard_name"`
+	ItemType        string                 `json:"item_type"`
+	Change          string                 `json:"change"`
+	PanelName       string                 `json:"panel_name"`
+	PanelType       string                 `json:"panel_type"`
+	BenchmarkPath   []string               `json:"benchmark_path"`
+	Resource        *string                `json:"resource"`
+	FromStatus      *string                `json:"from_status"`
+	ToStatus        *string                `json:"to_status"`
+	FromData        map[string]interface{} `json:"from_data"`
+	ToData          map[string]interface{} `json:"to_data"`
+}
+
+//// TABLE DEFINITION
+
+func tableSteampipeCloudWorkspaceSnapshotDiff(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "steampipecloud_workspace_snapshot_diff",
+		Description: "The panel rows and control results added, removed or changed between two snapshots of a dashboard.",
+		List: &plugin.ListConfig{
+			ParentHydrate: listQualifiedWorkspaces,
+			Hydrate:       listWorkspaceSnapshotDiffs,
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "from_snapshot_id",
+					Require: plugin.Required,
+				},
+				{
+					Name:    "to_snapshot_id",
+					Require: plugin.Required,
+				},
+				{
+					Name:    "identity_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "identity_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "item_type",
+					Require: plugin.Optional,
+				},
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			{
+				Name:        "identity_id",
+				Description: "The unique identifier of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "identity_handle",
+				Description: "The handle of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "workspace_id",
+				Description: "The unique identifier of the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "workspace_handle",
+				Description: "The handle of the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "from_snapshot_id",
+				Description: "The unique identifier of the earlier snapshot, which must be given in the query.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "to_snapshot_id",
+				Description: "The unique identifier of the later snapshot, which must be given in the query.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "dashboard_name",
+				Description: "The name of the dashboard of both snapshots.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "item_type",
+				Description: "The type of item compared, i.e. panel_row or control_result.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "change",
+				Description: "How the item changed, i.e. added, removed or changed. Only control results can be changed.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "panel_name",
+				Description: "The name of the panel, or of the control for a control result.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "panel_type",
+				Description: "The type of the panel, e.g. chart, table or control.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "benchmark_path",
+				Description: "The names of the benchmarks above the control of a control result, from the top of the benchmark tree.",
+				Type:        proto.ColumnType_JSON,
+			},
+			{
+				Name:        "resource",
+				Description: "The resource of a control result.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "from_status",
+				Description: "The status of a control result in the earlier snapshot.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "to_status",
+				Description: "The status of a control result in the later snapshot.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "from_data",
+				Description: "The item in the earlier snapshot, if it was not added.",
+				Type:        proto.ColumnType_JSON,
+			},
+			{
+				Name:        "to_data",
+				Description: "The item in the later snapshot, if it was not removed.",
+				Type:        proto.ColumnType_JSON,
+			},
+		}),
+	}
+}
+
+//// LIST FUNCTION
+
+func listWorkspaceSnapshotDiffs(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, workspace, err := connectQualifiedWorkspace(ctx, d, h)
+	if err != nil {
+		plugin.Logger(ctx).Error("listWorkspaceSnapshotDiffs", "connection_error", err)
+		return nil, err
+	}
+	if client == nil {
+		return nil, nil
+	}
+
+	// Both snapshots must belong to the workspace
+	var contents []*snapshotContent
+	for _, qual := range []string{"from_snapshot_id", "to_snapshot_id"} {
+		content, err := downloadSnapshotContent(ctx, d, h, client, workspace, d.EqualsQuals[qual].GetStringValue())
+		if err != nil {
+			plugin.Logger(ctx).Error("listWorkspaceSnapshotDiffs", "download_error", err)
+			return nil, err
+		}
+		if content == nil {
+			return nil, nil
+		}
+		contents = append(contents, content)
+	}
+	from, to := contents[0], contents[1]
+
+	if from.data.Layout.Name != to.data.Layout.Name {
+		return nil, fmt.Errorf("snapshots %s and %s are of different dashboards, %s and %s", from.snapshotId, to.snapshotId, from.data.Layout.Name, to.data.Layout.Name)
+	}
+
+	var diffs []*SnapshotDiff
+	itemType := d.EqualsQuals["item_type"].GetStringValue()
+	if itemType == "" || itemType == snapshotDiffPanelRow {
+		diffs = append(diffs, diffSnapshotPanelRows(from, to)...)
+	}
+	if itemType == "" || itemType == snapshotDiffControlResult {
+		diffs = append(diffs, diffSnapshotControlResults(from, to)...)
+	}
+
+	for _, diff := range diffs {
+		diff.IdentityId = client.identity.Id
+		diff.IdentityHandle = client.identity.Handle
+		diff.WorkspaceId = workspace.Id
+		diff.WorkspaceHandle = workspace.Handle
+		diff.FromSnapshotId = from.snapshotId
+		diff.ToSnapshotId = to.snapshotId
+		diff.DashboardName = from.data.Layout.Name
+		d.StreamListItem(ctx, diff)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// diffSnapshotPanelRows returns the rows added to and removed from each
+// panel. Panel rows have no identity, so a row whose values changed is
+// reported as removed and added. Control panels are compared by
+// diffSnapshotControlResults instead.
+func diffSnapshotPanelRows(from, to *snapshotContent) []*SnapshotDiff {
+	fromPanels := map[string]*SnapshotPanel{}
+	for _, panel := range from.panels() {
+		fromPanels[panel.Name] = panel
+	}
+	toPanels := map[string]*SnapshotPanel{}
+	for _, panel := range to.panels() {
+		toPanels[panel.Name] = panel
+	}
+
+	var names []string
+	for name := range fromPanels {
+		names = append(names, name)
+	}
+	for name := range toPanels {
+		if _, ok := fromPanels[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diffs []*SnapshotDiff
+	for _, name := range names {
+		fromPanel, toPanel := fromPanels[name], toPanels[name]
+		panelType := ""
+		if fromPanel != nil {
+			panelType = fromPanel.PanelType
+		} else {
+			panelType = toPanel.PanelType
+		}
+		if panelType == "control" {
+			continue
+		}
+
+		// Count each distinct row, so that duplicate rows are matched up
+		remaining := map[string]int{}
+		if toPanel != nil {
+			for _, row := range toPanel.rows {
+				remaining[snapshotRowKey(row)]++
+			}
+		}
+		if fromPanel != nil {
+			for _, row := range fromPanel.rows {
+				key := snapshotRowKey(row)
+				if remaining[key] > 0 {
+					remaining[key]--
+					continue
+				}
+				diffs = append(diffs, &SnapshotDiff{ItemType: snapshotDiffPanelRow, Change: snapshotDiffRemoved, PanelName: name, PanelType: panelType, FromData: row})
+			}
+		}
+		if toPanel != nil {
+			for _, row := range toPanel.rows {
+				key := snapshotRowKey(row)
+				if remaining[key] > 0 {
+					remaining[key]--
+					diffs = append(diffs, &SnapshotDiff{ItemType: snapshotDiffPanelRow, Change: snapshotDiffAdded, PanelName: name, PanelType: panelType, ToData: row})
+				}
+			}
+		}
+	}
+	return diffs
+}
+
+// diffSnapshotControlResults returns the control results added, removed or
+// changed, matching results by benchmark path, control, resource and
+// dimensions. Results which are otherwise the same, e.g. those without a
+// resource, are matched by their order within the control.
+func diffSnapshotControlResults(from, to *snapshotContent) []*SnapshotDiff {
+	fromResults := from.controlResults()
+	fromKeys := controlResultKeys(fromResults)
+	fromByKey := map[string]*SnapshotControlResult{}
+	for i, result := range fromResults {
+		fromByKey[fromKeys[i]] = result
+	}
+
+	var diffs []*SnapshotDiff
+	matched := map[string]bool{}
+	toResults := to.controlResults()
+	for i, key := range controlResultKeys(toResults) {
+		result := toResults[i]
+		fromResult, ok := fromByKey[key]
+		switch {
+		case !ok:
+			diffs = append(diffs, newControlResultDiff(snapshotDiffAdded, nil, result))
+		case !sameControlResult(fromResult, result):
+			diffs = append(diffs, newControlResultDiff(snapshotDiffChanged, fromResult, result))
+		}
+		matched[key] = true
+	}
+	for i, result := range fromResults {
+		if !matched[fromKeys[i]] {
+			diffs = append(diffs, newControlResultDiff(snapshotDiffRemoved, result, nil))
+		}
+	}
+	return diffs
+}
+
+// controlResultKeys returns a key for each control result which is unique
+// within the snapshot and identifies the same result in another snapshot.
+func controlResultKeys(results []*SnapshotControlResult) []string {
+	keys := make([]string, len(results))
+	occurrences := map[string]int{}
+	for i, result := range results {
+		parts := append(append([]string{}, result.BenchmarkPath...), result.ControlName)
+		if result.Resource != nil {
+			parts = append(parts, "resource", *result.Resource)
+		}
+		// map keys are sorted when marshalled
+		dimensions, _ := json.Marshal(result.Dimensions)
+		key := strings.Join(append(parts, string(dimensions)), "\x00")
+		keys[i] = fmt.Sprintf("%s\x00%d", key, occurrences[key])
+		occurrences[key]++
+	}
+	return keys
+}
+
+func newControlResultDiff(change string, from, to *SnapshotControlResult) *SnapshotDiff {
+	diff := &SnapshotDiff{ItemType: snapshotDiffControlResult, Change: change, PanelType: "control"}
+	if from != nil {
+		diff.PanelName = from.ControlName
+		diff.BenchmarkPath = from.BenchmarkPath
+		diff.Resource = from.Resource
+		diff.FromStatus = from.Status
+		diff.FromData = controlResultData(from)
+	}
+	if to != nil {
+		diff.PanelName = to.ControlName
+		diff.BenchmarkPath = to.BenchmarkPath
+		diff.Resource = to.Resource
+		diff.ToStatus = to.Status
+		diff.ToData = controlResultData(to)
+	}
+	return diff
+}
+
+func sameControlResult(a, b *SnapshotControlResult) bool {
+	return snapshotRowKey(controlResultData(a)) == snapshotRowKey(controlResultData(b))
+}
+
+// controlResultData returns the parts of a control result which are compared
+// between snapshots.
+func controlResultData(result *SnapshotControlResult) map[string]interface{} {
+	return map[string]interface{}{
+		"status":     result.Status,
+		"reason":     result.Reason,
+		"dimensions": result.Dimensions,
+	}
+}
+
+// snapshotRowKey returns a key which is equal for rows with equal values.
+func snapshotRowKey(row map[string]interface{}) string {
+	// map keys are sorted when marshalled
+	data, _ := json.Marshal(row)
+	return string(data)
+}
@@ -0,0 +1,157 @@
+package steampipecloud
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableSteampipeCloudWorkspaceSnapshotPanel(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "steampipecloud_workspace_snapshot_panel",
+		Description: "The panels of a dashboard snapshot, e.g. its charts, tables and controls.",
+		List: &plugin.ListConfig{
+			ParentHydrate: listQualifiedWorkspaces,
+			Hydrate:       listWorkspaceSnapshotPanels,
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "snapshot_id",
+					Require: plugin.Required,
+				},
+				{
+					Name:    "identity_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "identity_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "panel_type",
+					Require: plugin.Optional,
+				},
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			{
+				Name:        "identity_id",
+				Description: "The unique identifier of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "identity_handle",
+				Description: "The handle of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "workspace_id",
+				Description: "The unique identifier of the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "workspace_handle",
+				Description: "The handle of the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "snapshot_id",
+				Description: "The unique identifier of the snapshot, which must be given in the query.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "name",
+				Description: "The name of the panel, e.g. aws_insights.chart.dashboard_s3_bucket_count.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "panel_type",
+				Description: "The type of the panel, e.g. chart, table, card, benchmark or control.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "display_type",
+				Description: "How the panel is displayed, e.g. the type of a chart.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "title",
+				Description: "The title of the panel.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "status",
+				Description: "The status of the panel when the snapshot was taken, e.g. complete or error.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "sql",
+				Description: "The query run for the panel.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromField("SQL"),
+			},
+			{
+				Name:        "error",
+				Description: "The error returned by the panel's query, if it failed.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "columns",
+				Description: "The columns of the panel's query results.",
+				Type:        proto.ColumnType_JSON,
+			},
+			{
+				Name:        "row_count",
+				Description: "The number of rows in the panel's query results.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "properties",
+				Description: "The properties of the panel, e.g. the series of a chart.",
+				Type:        proto.ColumnType_JSON,
+			},
+		}),
+	}
+}
+
+//// LIST FUNCTION
+
+func listWorkspaceSnapshotPanels(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	content, err := getQualifiedSnapshotContent(ctx, d, h)
+	if err != nil {
+		plugin.Logger(ctx).Error("listWorkspaceSnapshotPanels", "download_error", err)
+		return nil, err
+	}
+	if content == nil {
+		return nil, nil
+	}
+
+	panelType := d.EqualsQuals["panel_type"].GetStringValue()
+	for _, panel := range content.panels() {
+		if panelType != "" && panel.PanelType != panelType {
+			continue
+		}
+		d.StreamListItem(ctx, panel)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
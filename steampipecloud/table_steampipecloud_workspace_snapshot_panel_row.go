@@ -0,0 +1,133 @@
+package steampipecloud
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableSteampipeCloudWorkspaceSnapshotPanelRow(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:        "steampipecloud_workspace_snapshot_panel_row",
+		Description: "The rows of the query results of the panels of a dashboard snapshot.",
+		List: &plugin.ListConfig{
+			ParentHydrate: listQualifiedWorkspaces,
+			Hydrate:       listWorkspaceSnapshotPanelRows,
+			KeyColumns: []*plugin.KeyColumn{
+				{
+					Name:    "snapshot_id",
+					Require: plugin.Required,
+				},
+				{
+					Name:    "identity_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "identity_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_handle",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "workspace_id",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "panel_name",
+					Require: plugin.Optional,
+				},
+				{
+					Name:    "panel_type",
+					Require: plugin.Optional,
+				},
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			{
+				Name:        "identity_id",
+				Description: "The unique identifier of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "identity_handle",
+				Description: "The handle of the identity which owns the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "workspace_id",
+				Description: "The unique identifier of the workspace.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "workspace_handle",
+				Description: "The handle of the workspace.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "snapshot_id",
+				Description: "The unique identifier of the snapshot, which must be given in the query.",
+				Type:        proto.ColumnType_STRING,
+				Transform:   transform.FromCamel(),
+			},
+			{
+				Name:        "panel_name",
+				Description: "The name of the panel whose query returned the row.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "panel_type",
+				Description: "The type of the panel, e.g. chart, table, card or control.",
+				Type:        proto.ColumnType_STRING,
+			},
+			{
+				Name:        "row_number",
+				Description: "The position of the row in the panel's query results, starting from 1.",
+				Type:        proto.ColumnType_INT,
+			},
+			{
+				Name:        "data",
+				Description: "The values of the row, keyed by column name.",
+				Type:        proto.ColumnType_JSON,
+			},
+		}),
+	}
+}
+
+//// LIST FUNCTION
+
+func listWorkspaceSnapshotPanelRows(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	content, err := getQualifiedSnapshotContent(ctx, d, h)
+	if err != nil {
+		plugin.Logger(ctx).Error("listWorkspaceSnapshotPanelRows", "download_error", err)
+		return nil, err
+	}
+	if content == nil {
+		return nil, nil
+	}
+
+	panelName := d.EqualsQuals["panel_name"].GetStringValue()
+	panelType := d.EqualsQuals["panel_type"].GetStringValue()
+	for _, panel := range content.panels() {
+		if (panelName != "" && panel.Name != panelName) || (panelType != "" && panel.PanelType != panelType) {
+			continue
+		}
+		for _, row := range panel.resultRows() {
+			d.StreamListItem(ctx, row)
+
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if d.RowsRemaining(ctx) == 0 {
+				return nil, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
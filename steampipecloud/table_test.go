@@ -0,0 +1,1262 @@
+package steampipecloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	openapi "github.com/turbot/steampipe-cloud-sdk-go"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/quals"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newFixtureCloud returns a mock populated with the user jane, who has a
+// workspace dev and is a member of the org acme, which has the workspaces
+// prod and stage.
+func newFixtureCloud(t *testing.T) *mockCloud {
+	m := newMockCloud(t)
+
+	jane := openapi.User{Id: "u_jane", Handle: "jane", Status: "accepted"}
+	acme := openapi.Org{Id: "o_acme", Handle: "acme", State: "enabled"}
+	m.setUser(jane)
+	m.addOrg(acme)
+
+	m.addWorkspace("user/jane", openapi.Workspace{Id: "w_dev", Handle: "dev", IdentityId: "u_jane", DesiredState: "enabled"})
+	m.addWorkspace("org/acme", openapi.Workspace{Id: "w_prod", Handle: "prod", IdentityId: "o_acme", DesiredState: "enabled"})
+	m.addWorkspace("org/acme", openapi.Workspace{Id: "w_stage", Handle: "stage", IdentityId: "o_acme", DesiredState: "enabled"})
+
+	aws := openapi.Connection{Id: "c_aws", Handle: "aws", IdentityId: "u_jane", Plugin: openapi.PtrString("aws")}
+	gcp := openapi.Connection{Id: "c_gcp", Handle: "gcp", IdentityId: "o_acme", Plugin: openapi.PtrString("gcp")}
+	m.add("user/jane/connection", aws)
+	m.add("org/acme/connection", gcp)
+	m.add("actor/conn", aws, gcp)
+
+	m.add("user/jane/audit_log",
+		openapi.AuditRecord{Id: "a_1", ActionType: "workspace.create", ActorHandle: "jane", ActorId: "u_jane", IdentityHandle: "jane", IdentityId: "u_jane"},
+		openapi.AuditRecord{Id: "a_2", ActionType: "workspace.update", ActorHandle: "jane", ActorId: "u_jane", IdentityHandle: "jane", IdentityId: "u_jane"},
+		openapi.AuditRecord{Id: "a_3", ActionType: "connection.create", ActorHandle: "jane", ActorId: "u_jane", IdentityHandle: "jane", IdentityId: "u_jane"},
+	)
+
+	m.add("user/jane/workspace/dev/db_log",
+		openapi.LogRecord{Id: "l_1", ActorHandle: "jane", ActorId: "u_jane", WorkspaceHandle: "dev", WorkspaceId: "w_dev", Query: openapi.PtrString("select 1")},
+	)
+	m.add("org/acme/workspace/prod/db_log",
+		openapi.LogRecord{Id: "l_2", ActorHandle: "jane", ActorId: "u_jane", WorkspaceHandle: "prod", WorkspaceId: "w_prod", Query: openapi.PtrString("select 2")},
+		openapi.LogRecord{Id: "l_3", ActorHandle: "jane", ActorId: "u_jane", WorkspaceHandle: "prod", WorkspaceId: "w_prod", Query: openapi.PtrString("select 3")},
+	)
+
+	m.add("org/acme/workspace/prod/aggregator",
+		openapi.WorkspaceAggregator{Id: "ag_1", Handle: "all_aws", IdentityId: "o_acme", WorkspaceId: "w_prod", Plugin: "aws", Connections: []string{"aws*"}},
+	)
+	m.add("org/acme/workspace/prod/conn",
+		openapi.WorkspaceConn{Id: "wc_1", ConnectionId: "c_gcp", Connection: &gcp, IdentityId: "o_acme", WorkspaceId: "w_prod"},
+	)
+
+	m.add("user/jane/workspace/dev/mod",
+		openapi.WorkspaceMod{Id: "m_1", Alias: openapi.PtrString("aws_compliance"), Path: openapi.PtrString("github.com/turbot/steampipe-mod-aws-compliance"), IdentityId: "u_jane", WorkspaceId: "w_dev"},
+	)
+	m.add("user/jane/workspace/dev/mod/aws_compliance/variable",
+		openapi.WorkspaceModVariable{Id: "v_1", Name: openapi.PtrString("regions"), ModAlias: openapi.PtrString("aws_compliance")},
+	)
+
+	m.add("org/acme/workspace/prod/pipeline",
+		openapi.Pipeline{Id: "p_1", Pipeline: "snapshot.dashboard", IdentityId: "o_acme", WorkspaceId: openapi.PtrString("w_prod"), State: "enabled", DesiredState: "enabled"},
+	)
+	m.add("org/acme/workspace/prod/process",
+		openapi.SpProcess{Id: "sp_1", Type: "pipeline.command.run", IdentityId: openapi.PtrString("o_acme"), WorkspaceId: openapi.PtrString("w_prod"), State: openapi.PtrString("finished")},
+	)
+	m.add("user/jane/process",
+		openapi.SpProcess{Id: "sp_2", Type: "workspace.create", IdentityId: openapi.PtrString("u_jane"), WorkspaceId: openapi.PtrString("w_dev"), State: openapi.PtrString("finished")},
+	)
+
+	m.add("user/jane/workspace/dev/snapshot",
+		openapi.WorkspaceSnapshot{Id: "snap_1", IdentityId: "u_jane", WorkspaceId: "w_dev", DashboardName: "aws_compliance.benchmark.cis"},
+		openapi.WorkspaceSnapshot{Id: "snap_2", IdentityId: "u_jane", WorkspaceId: "w_dev", DashboardName: "aws_compliance.benchmark.cis"},
+		openapi.WorkspaceSnapshot{Id: "snap_3", IdentityId: "u_jane", WorkspaceId: "w_dev", DashboardName: "aws_insights.dashboard.s3"},
+	)
+
+	orgMember := openapi.OrgUser{Id: "om_1", OrgId: "o_acme", UserHandle: "jane", UserId: "u_jane", Status: "accepted", Role: openapi.PtrString("owner")}
+	m.add("org/acme/member", orgMember)
+	m.set("org/acme/member/jane", orgMember)
+
+	workspaceMember := openapi.OrgWorkspaceUser{Id: "owm_1", OrgId: "o_acme", UserHandle: "jane", UserId: "u_jane", WorkspaceHandle: "prod", WorkspaceId: "w_prod", Status: "accepted"}
+	m.add("org/acme/workspace/prod/member", workspaceMember)
+	m.set("org/acme/workspace/prod/member/jane", workspaceMember)
+
+	m.add("user/jane/token", openapi.Token{Id: "tok_1", UserId: "u_jane", Status: "active", Last4: openapi.PtrString("abcd")})
+	m.add("user/jane/email", openapi.UserEmail{Email: "jane@example.com", Status: "verified"})
+	m.set("user/jane/preferences", openapi.UserPreferences{CommunicationProductUpdates: "enabled"})
+
+	return m
+}
+
+func TestListWorkspacesPaginates(t *testing.T) {
+	m := newFixtureCloud(t)
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 workspaces, got %d", len(rows))
+	}
+	// three workspaces with a page size of two
+	if count := m.requestCount("actor/workspace"); count != 2 {
+		t.Errorf("expected 2 list requests, got %d", count)
+	}
+
+	owners := map[string]string{}
+	for _, row := range rows {
+		owners[row["handle"].(string)] = row["identity_handle"].(string)
+	}
+	expected := map[string]string{"dev": "jane", "prod": "acme", "stage": "acme"}
+	for handle, owner := range expected {
+		if owners[handle] != owner {
+			t.Errorf("expected workspace %s to belong to %s, got %q", handle, owner, owners[handle])
+		}
+	}
+}
+
+func TestListWorkspacesByIdentity(t *testing.T) {
+	cases := []struct {
+		name     string
+		quals    []*quals.Qual
+		expected int
+	}{
+		{"handle", []*quals.Qual{equalsQual("identity_handle", "acme")}, 2},
+		{"id", []*quals.Qual{equalsQual("identity_id", "o_acme")}, 2},
+		{"user", []*quals.Qual{equalsQual("identity_handle", "jane")}, 1},
+		{"matching handle and id", []*quals.Qual{equalsQual("identity_handle", "acme"), equalsQual("identity_id", "o_acme")}, 2},
+		{"mismatched handle and id", []*quals.Qual{equalsQual("identity_handle", "jane"), equalsQual("identity_id", "o_acme")}, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newFixtureCloud(t)
+			rows, err := m.list(tableQuery{table: "steampipecloud_workspace", quals: tc.quals})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != tc.expected {
+				t.Errorf("expected %d workspaces, got %d", tc.expected, len(rows))
+			}
+		})
+	}
+}
+
+func TestGetWorkspaceNotFound(t *testing.T) {
+	m := newFixtureCloud(t)
+
+	row, err := m.get(tableQuery{table: "steampipecloud_workspace", quals: []*quals.Qual{
+		equalsQual("identity_handle", "acme"),
+		equalsQual("handle", "missing"),
+	}})
+	if err != nil {
+		t.Fatalf("expected a 404 to be ignored, got %v", err)
+	}
+	if row != nil {
+		t.Errorf("expected no row, got %v", row)
+	}
+}
+
+func TestListRetriesRateLimitedRequests(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.fail("org/acme/workspace", http.StatusTooManyRequests)
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace", quals: []*quals.Qual{equalsQual("identity_handle", "acme")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("expected 2 workspaces, got %d", len(rows))
+	}
+	if count := m.requestCount("org/acme/workspace"); count != 2 {
+		t.Errorf("expected the rate limited request to be retried once, got %d requests", count)
+	}
+}
+
+func TestListRetriesServerErrors(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.fail("org/acme/workspace", http.StatusBadGateway)
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace", quals: []*quals.Qual{equalsQual("identity_handle", "acme")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("expected 2 workspaces, got %d", len(rows))
+	}
+	if count := m.requestCount("org/acme/workspace"); count != 2 {
+		t.Errorf("expected the failed request to be retried once, got %d requests", count)
+	}
+}
+
+func TestListReturnsForbiddenErrors(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.fail("org/acme/workspace", http.StatusForbidden)
+
+	_, err := m.list(tableQuery{table: "steampipecloud_workspace", quals: []*quals.Qual{equalsQual("identity_handle", "acme")}})
+	if !isAPIError(err, apiErrorForbidden) {
+		t.Fatalf("expected a forbidden error, got %v", err)
+	}
+	if count := m.requestCount("org/acme/workspace"); count != 1 {
+		t.Errorf("expected the forbidden request not to be retried, got %d requests", count)
+	}
+}
+
+func TestListOrgForbiddenErrors(t *testing.T) {
+	query := tableQuery{table: "steampipecloud_organization_member"}
+
+	m := newFixtureCloud(t)
+	m.fail("org/acme/member", http.StatusForbidden)
+	if _, err := m.list(query); !isAPIError(err, apiErrorForbidden) {
+		t.Fatalf("expected a forbidden error, got %v", err)
+	}
+
+	m = newFixtureCloud(t)
+	m.fail("org/acme/member", http.StatusForbidden)
+	ignore := true
+	m.connectionConfig.IgnoreOrgForbiddenErrors = &ignore
+	rows, err := m.list(query)
+	if err != nil {
+		t.Fatalf("expected the forbidden error to be ignored, got %v", err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("expected no members, got %d", len(rows))
+	}
+}
+
+func TestListStopsAtLimit(t *testing.T) {
+	m := newFixtureCloud(t)
+
+	limit := int64(1)
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace", limit: &limit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Errorf("expected 1 workspace, got %d", len(rows))
+	}
+	if count := m.requestCount("actor/workspace"); count != 1 {
+		t.Errorf("expected 1 list request, got %d", count)
+	}
+}
+
+func TestListSnapshotsEscapesFilter(t *testing.T) {
+	m := newFixtureCloud(t)
+
+	_, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot", quals: []*quals.Qual{
+		equalsQual("dashboard_name", "aws_compliance.dashboard.o'brien"),
+		operatorQual("visibility", "<>", &proto.QualValue{Value: &proto.QualValue_StringValue{StringValue: "anyone_with_link"}}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "dashboard_name = 'aws_compliance.dashboard.o''brien' and visibility <> 'anyone_with_link'"
+	if where := m.where("user/jane/workspace/dev/snapshot"); where != expected {
+		t.Errorf("expected where %q, got %q", expected, where)
+	}
+}
+
+func TestListAuditLogsPushesDownFilter(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.add("org/acme/audit_log",
+		openapi.AuditRecord{Id: "a_4", ActionType: "workspace.create", ActorHandle: "jane", ActorId: "u_jane", IdentityHandle: "acme", IdentityId: "o_acme"},
+	)
+
+	since := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	rows, err := m.list(tableQuery{table: "steampipecloud_audit_log", quals: []*quals.Qual{
+		equalsQual("identity_handle", "acme"),
+		operatorQual("created_at", ">=", &proto.QualValue{Value: &proto.QualValue_TimestampValue{TimestampValue: timestamppb.New(since)}}),
+		equalsQual("action_type", "workspace.create"),
+		equalsQual("query_where", "actor_handle = 'jane'"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Errorf("expected 1 audit log, got %d", len(rows))
+	}
+
+	expected := "created_at >= '2023-05-01 12:00:00.00000' and action_type = 'workspace.create' and (actor_handle = 'jane')"
+	if where := m.where("org/acme/audit_log"); where != expected {
+		t.Errorf("expected where %q, got %q", expected, where)
+	}
+}
+
+func TestListDBLogsForWorkspace(t *testing.T) {
+	m := newFixtureCloud(t)
+
+	since := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_db_log", quals: []*quals.Qual{
+		equalsQual("identity_handle", "acme"),
+		equalsQual("workspace_handle", "prod"),
+		operatorQual("log_timestamp", ">", &proto.QualValue{Value: &proto.QualValue_TimestampValue{TimestampValue: timestamppb.New(since)}}),
+		operatorQual("duration", ">=", &proto.QualValue{Value: &proto.QualValue_DoubleValue{DoubleValue: 1000}}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 db logs, got %d", len(rows))
+	}
+	if rows[0]["identity_handle"] != "acme" || rows[0]["identity_id"] != "o_acme" {
+		t.Errorf("expected the identity of the workspace, got %v", rows[0])
+	}
+	if rows[0]["statement_type"] != "select" || rows[0]["query_fingerprint"] != rows[1]["query_fingerprint"] {
+		t.Errorf("expected select queries of the same shape, got %v and %v", rows[0], rows[1])
+	}
+
+	// only the qualified workspace is fetched, rather than listing them all
+	for _, path := range []string{"actor/workspace", "org/acme/workspace", "user/jane/workspace/dev/db_log"} {
+		if count := m.requestCount(path); count != 0 {
+			t.Errorf("expected no requests for %s, got %d", path, count)
+		}
+	}
+
+	expected := "log_timestamp > '2023-05-01 12:00:00.00000' and duration >= 1000"
+	if where := m.where("org/acme/workspace/prod/db_log"); where != expected {
+		t.Errorf("expected where %q, got %q", expected, where)
+	}
+}
+
+func TestListDBLogsSkipsOtherWorkspaces(t *testing.T) {
+	m := newFixtureCloud(t)
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_db_log", quals: []*quals.Qual{
+		equalsQual("workspace_handle", "dev"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Errorf("expected 1 db log, got %d", len(rows))
+	}
+	if count := m.requestCount("org/acme/workspace/prod/db_log"); count != 0 {
+		t.Errorf("expected no db log requests for other workspaces, got %d", count)
+	}
+}
+
+func TestListDBLogsByWorkspaceId(t *testing.T) {
+	m := newFixtureCloud(t)
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_db_log", quals: []*quals.Qual{
+		equalsQual("identity_id", "o_acme"),
+		equalsQual("workspace_id", "w_prod"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 db logs, got %d", len(rows))
+	}
+	if rows[0]["workspace_handle"] != "prod" {
+		t.Errorf("expected logs of the prod workspace, got %v", rows[0])
+	}
+
+	// workspaces are only fetched by handle, so the id is found by listing the
+	// identity's workspaces
+	if count := m.requestCount("org/acme/workspace"); count == 0 {
+		t.Error("expected the org workspaces to be listed")
+	}
+	if count := m.requestCount("actor/workspace"); count != 0 {
+		t.Errorf("expected no requests for actor/workspace, got %d", count)
+	}
+}
+
+func TestListQualifiedWorkspacesNotFound(t *testing.T) {
+	m := newFixtureCloud(t)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	p := Plugin(ctx)
+	table := p.TableMap["steampipecloud_workspace_db_log"]
+	table.Plugin = p
+	for _, q := range [][]*quals.Qual{
+		{equalsQual("identity_handle", "jane"), equalsQual("workspace_handle", "does-not-exist")},
+		{equalsQual("identity_handle", "nobody"), equalsQual("workspace_handle", "dev")},
+		{equalsQual("identity_handle", "jane"), equalsQual("workspace_id", "w_missing")},
+	} {
+		d, err := m.newQueryData(t, table, table.List.KeyColumns, tableQuery{table: table.Name, quals: q})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var items []interface{}
+		d.StreamListItem = func(ctx context.Context, values ...interface{}) {
+			items = append(items, values...)
+		}
+
+		// called directly, as the harness would ignore the 404
+		if _, err := listQualifiedWorkspaces(ctx, d, &plugin.HydrateData{}); err != nil {
+			t.Errorf("expected no error for a missing workspace, got %v", err)
+		}
+		if len(items) != 0 {
+			t.Errorf("expected no workspaces, got %v", items)
+		}
+	}
+}
+
+func TestListDBLogSummaries(t *testing.T) {
+	m := newFixtureCloud(t)
+	logRecord := func(id, actor, query string, duration int32) openapi.LogRecord {
+		return openapi.LogRecord{Id: id, ActorHandle: actor, ActorId: "u_" + actor, WorkspaceHandle: "stage", WorkspaceId: "w_stage", Query: openapi.PtrString(query), Duration: openapi.PtrInt32(duration), LogTimestamp: openapi.PtrString("2023-05-01T12:0" + id + ":00Z")}
+	}
+	m.add("org/acme/workspace/stage/db_log",
+		logRecord("1", "jane", "select * from aws_s3_bucket where name = 'a'", 10),
+		logRecord("2", "jane", "select * from aws_s3_bucket where name = 'b'", 20),
+		logRecord("3", "jane", "select * from aws_s3_bucket where name = 'c'", 300),
+		logRecord("4", "jane", "select * from aws_vpc", 5),
+		logRecord("5", "joe", "select * from aws_s3_bucket where name = 'd'", 40),
+	)
+
+	since := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_db_log_summary", quals: []*quals.Qual{
+		equalsQual("identity_handle", "acme"),
+		equalsQual("workspace_handle", "stage"),
+		operatorQual("window_start", ">", &proto.QualValue{Value: &proto.QualValue_TimestampValue{TimestampValue: timestamppb.New(since)}}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 summaries, got %d", len(rows))
+	}
+
+	summary := rows[0]
+	if summary["actor_handle"] != "jane" || summary["query_count"] != int64(3) || summary["statement_type"] != "select" {
+		t.Errorf("expected 3 bucket queries by jane first, got %v", summary)
+	}
+	if summary["min_duration"] != float64(10) || summary["max_duration"] != float64(300) || summary["p50_duration"] != float64(20) || summary["p95_duration"] != float64(300) {
+		t.Errorf("unexpected durations %v", summary)
+	}
+	if summary["first_log_timestamp"] != "2023-05-01T12:01:00Z" || summary["last_log_timestamp"] != "2023-05-01T12:03:00Z" {
+		t.Errorf("unexpected log timestamps %v", summary)
+	}
+
+	// the window ends at the time of the query
+	expected := "log_timestamp > '2023-05-01 00:00:00.00000' and log_timestamp < '"
+	if where := m.where("org/acme/workspace/stage/db_log"); !strings.HasPrefix(where, expected) {
+		t.Errorf("expected where starting %q, got %q", expected, where)
+	}
+	if windowStart, ok := summary["window_start"].(time.Time); !ok || !windowStart.After(since) {
+		t.Errorf("expected the window to start after %v, got %v", since, summary["window_start"])
+	}
+}
+
+func TestDurationSketchPercentiles(t *testing.T) {
+	var sketch durationSketch
+	for duration := 1; duration <= 100000; duration++ {
+		sketch.add(float64(duration))
+	}
+	for p, expected := range map[float64]float64{50: 50000, 95: 95000} {
+		if actual := sketch.percentile(p); math.Abs(actual-expected) > expected/100 {
+			t.Errorf("expected p%v within 1%% of %v, got %v", p, expected, actual)
+		}
+	}
+	if len(sketch.buckets) > 2000 {
+		t.Errorf("expected a bounded number of buckets, got %d", len(sketch.buckets))
+	}
+}
+
+func TestListInvites(t *testing.T) {
+	m := newFixtureCloud(t)
+	expiresAt := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	m.add("org/acme/member", map[string]interface{}{
+		"id": "om_2", "org_id": "o_acme", "user_handle": "joe", "user_id": "u_joe", "status": "invited",
+		"created_at": time.Now().UTC().Format(time.RFC3339), "created_by": map[string]interface{}{"id": "u_jane", "handle": "jane"},
+		"email": "joe@example.com", "expires_at": expiresAt,
+	})
+	m.add("org/acme/workspace/stage/member", openapi.OrgWorkspaceUser{
+		Id: "owm_2", OrgId: "o_acme", UserHandle: "ann", UserId: "u_ann", WorkspaceHandle: "stage", WorkspaceId: "w_stage", Status: "pending",
+		CreatedAt: time.Now().Add(-10 * 24 * time.Hour).UTC().Format(time.RFC3339),
+	})
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_invite"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 invites, got %d", len(rows))
+	}
+
+	invites := map[string]testRow{}
+	for _, row := range rows {
+		invites[row["id"].(string)] = row
+	}
+	orgInvite, workspaceInvite := invites["om_2"], invites["owm_2"]
+	if orgInvite["invite_type"] != "org" || orgInvite["invitee_email"] != "joe@example.com" || orgInvite["created_by_handle"] != "jane" || orgInvite["is_stale"] != true {
+		t.Errorf("unexpected org invite %v", orgInvite)
+	}
+	if workspaceInvite["invite_type"] != "workspace" || workspaceInvite["workspace_handle"] != "stage" || workspaceInvite["is_stale"] != true {
+		t.Errorf("unexpected workspace invite %v", workspaceInvite)
+	}
+
+	row, err := m.get(tableQuery{table: "steampipecloud_invite", quals: []*quals.Qual{equalsQual("id", "owm_2")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row["user_handle"] != "ann" {
+		t.Errorf("expected the invite for ann, got %v", row)
+	}
+}
+
+func TestListInvitesSkipsForbiddenWorkspaces(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.add("org/acme/member", openapi.OrgUser{Id: "om_2", OrgId: "o_acme", UserHandle: "joe", UserId: "u_joe", Status: "invited"})
+	m.add("org/acme/workspace/stage/member", openapi.OrgWorkspaceUser{Id: "owm_2", OrgId: "o_acme", UserHandle: "ann", UserId: "u_ann", WorkspaceHandle: "stage", WorkspaceId: "w_stage", Status: "pending"})
+	m.fail("org/acme/workspace/prod/member", http.StatusForbidden)
+	ignore := true
+	m.connectionConfig.IgnoreOrgForbiddenErrors = &ignore
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_invite"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]bool{}
+	for _, row := range rows {
+		ids[row["id"].(string)] = true
+	}
+	if !ids["om_2"] || !ids["owm_2"] {
+		t.Errorf("expected the invites of the org and its other workspaces, got %v", ids)
+	}
+}
+
+func TestListWorkspaceAccess(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.add("org/acme/member",
+		openapi.OrgUser{Id: "om_2", OrgId: "o_acme", UserHandle: "bob", UserId: "u_bob", Status: "accepted", Role: openapi.PtrString("member")},
+		openapi.OrgUser{Id: "om_3", OrgId: "o_acme", UserHandle: "carol", UserId: "u_carol", Status: "invited", Role: openapi.PtrString("owner")},
+	)
+	m.add("org/acme/workspace/prod/member",
+		openapi.OrgWorkspaceUser{Id: "owm_2", OrgId: "o_acme", UserHandle: "bob", UserId: "u_bob", WorkspaceHandle: "prod", WorkspaceId: "w_prod", Status: "accepted", Role: openapi.PtrString("reader")},
+	)
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_access"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	access := map[string]string{}
+	for _, row := range rows {
+		access[row["workspace_handle"].(string)+"/"+row["user_handle"].(string)] = row["effective_role"].(string) + " via " + row["grant_path"].(string)
+	}
+	expected := map[string]string{
+		"dev/jane":   "owner via owner",
+		"prod/jane":  "owner via org_role",
+		"prod/bob":   "reader via workspace_member",
+		"stage/jane": "owner via org_role",
+	}
+	if !reflect.DeepEqual(access, expected) {
+		t.Errorf("expected access %v, got %v", expected, access)
+	}
+
+	// the org members are listed once for all of the org's workspaces, in
+	// two pages of the mock's page size
+	if count := m.requestCount("org/acme/member"); count != 2 {
+		t.Errorf("expected 2 org member list requests, got %d", count)
+	}
+}
+
+func TestListPipelineHealth(t *testing.T) {
+	m := newFixtureCloud(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	at := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+	m.add("org/acme/workspace/stage/pipeline", openapi.Pipeline{
+		Id: "p_2", Pipeline: "snapshot.dashboard", IdentityId: "o_acme", WorkspaceId: openapi.PtrString("w_stage"), DesiredState: "enabled",
+		Frequency: openapi.PipelineFrequency{Type: "interval", Schedule: openapi.PtrString("hourly")}, CreatedAt: at(48 * time.Hour),
+	})
+	process := func(id, state string, ago, ran time.Duration) openapi.SpProcess {
+		return openapi.SpProcess{Id: id, PipelineId: openapi.PtrString("p_2"), State: openapi.PtrString(state), CreatedAt: at(ago), UpdatedAt: at(ago - ran)}
+	}
+	// the oldest process is given in another time zone, so it is the most
+	// recent if the times are compared as strings
+	oldest := process("sp_3", "finished", 5*time.Hour, 10*time.Second)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	oldest.CreatedAt = now.Add(-5 * time.Hour).In(tokyo).Format(time.RFC3339)
+	m.add("org/acme/workspace/stage/process",
+		oldest,
+		process("sp_4", "failed", 4*time.Hour, 30*time.Second),
+		process("sp_5", "failed", 3*time.Hour, 20*time.Second),
+	)
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_pipeline_health", quals: []*quals.Qual{
+		equalsQual("identity_handle", "acme"),
+		equalsQual("workspace_handle", "stage"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 pipeline, got %d", len(rows))
+	}
+
+	health := rows[0]
+	if health["process_count"] != int64(3) || health["success_count"] != int64(1) || health["failure_count"] != int64(2) || health["consecutive_failures"] != int64(2) {
+		t.Errorf("unexpected counts %v", health)
+	}
+	if health["mean_duration"] != float64(20) || health["max_duration"] != float64(30) || health["last_state"] != "failed" {
+		t.Errorf("unexpected durations %v", health)
+	}
+	if health["is_overdue"] != true {
+		t.Errorf("expected an hourly pipeline last run 3 hours ago to be overdue, got %v", health)
+	}
+
+	windowStart := now.Add(-defaultPipelineHealthWindow)
+	if where := m.where("org/acme/workspace/stage/process"); !strings.HasPrefix(where, "pipeline_id = 'p_2' and created_at >= '"+windowStart.Format("2006-01-02 15:04")) {
+		t.Errorf("unexpected where %q", where)
+	}
+}
+
+func TestListProcessesPushesDownFilter(t *testing.T) {
+	m := newFixtureCloud(t)
+	created := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	m.add("user/jane/process", openapi.SpProcess{
+		Id: "sp_3", Type: "pipeline.command.run", IdentityId: openapi.PtrString("u_jane"), State: openapi.PtrString("failed"),
+		CreatedAt: created.Format(time.RFC3339), UpdatedAt: created.Add(90 * time.Second).Format(time.RFC3339),
+	})
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_process", quals: []*quals.Qual{
+		equalsQual("identity_handle", "jane"),
+		operatorQual("state", "<>", &proto.QualValue{Value: &proto.QualValue_StringValue{StringValue: "finished"}}),
+		equalsQual("type", "pipeline.command.run"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "state <> 'finished' and type = 'pipeline.command.run'"
+	if where := m.where("user/jane/process"); where != expected {
+		t.Errorf("expected where %q, got %q", expected, where)
+	}
+
+	var failed testRow
+	for _, row := range rows {
+		if row["id"] == "sp_3" {
+			failed = row
+		}
+	}
+	if failed == nil {
+		t.Fatalf("expected process sp_3 in %v", rows)
+	}
+	if failed["is_terminal"] != true || failed["outcome"] != "failure" || failed["duration"] != float64(90) {
+		t.Errorf("unexpected lifecycle %v", failed)
+	}
+}
+
+func TestListProcessLogs(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.set("org/acme/workspace/prod/process/sp_1/log/process.jsonl", []byte(
+		`{"timestamp":"2023-05-01T12:00:00Z","level":"INFO","message":"starting pipeline","step":1}`+"\n"+
+			`{"timestamp":"2023-05-01T12:00:05Z","level":"ERROR","message":"snapshot failed"}`+"\n"+
+			"\n"+
+			"panic: plain text\n",
+	))
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_process_log", quals: []*quals.Qual{
+		equalsQual("process_id", "sp_1"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 log lines, got %d", len(rows))
+	}
+
+	for _, row := range rows {
+		if row["workspace_handle"] != "prod" || row["process_id"] != "sp_1" {
+			t.Errorf("expected lines of sp_1 in prod, got %v", row)
+		}
+	}
+	byLine := map[int64]testRow{}
+	for _, row := range rows {
+		byLine[row["line_number"].(int64)] = row
+	}
+	if byLine[2]["level"] != "error" || byLine[2]["message"] != "snapshot failed" {
+		t.Errorf("unexpected error line %v", byLine[2])
+	}
+	if byLine[4]["message"] != "panic: plain text" || byLine[4]["level"] != nil {
+		t.Errorf("unexpected plain text line %v", byLine[4])
+	}
+}
+
+// testSnapshotData returns the payload of a snapshot of a benchmark with one
+// control, whose results are given as resource -> status, and a chart.
+func testSnapshotData(controlStatuses map[string]string) openapi.WorkspaceSnapshotData {
+	var controlRows []interface{}
+	for resource, status := range controlStatuses {
+		controlRows = append(controlRows, map[string]interface{}{"resource": resource, "status": status, "reason": resource + " is " + status, "region": "us-east-1"})
+	}
+	sort.Slice(controlRows, func(i, j int) bool {
+		return controlRows[i].(map[string]interface{})["resource"].(string) < controlRows[j].(map[string]interface{})["resource"].(string)
+	})
+
+	control := "aws_compliance.control.s3_bucket_versioning_enabled"
+	return openapi.WorkspaceSnapshotData{
+		SchemaVersion: "20221222",
+		Layout: openapi.WorkspaceSnapshotDataLayout{Name: "aws_compliance.benchmark.cis", PanelType: "benchmark", Children: &[]openapi.WorkspaceSnapshotDataLayout{
+			{Name: "aws_compliance.benchmark.cis_s3", PanelType: "benchmark", Children: &[]openapi.WorkspaceSnapshotDataLayout{
+				{Name: control, PanelType: "control"},
+			}},
+			{Name: "aws_compliance.chart.buckets", PanelType: "chart"},
+		}},
+		Panels: map[string]interface{}{
+			"aws_compliance.benchmark.cis":    map[string]interface{}{"name": "aws_compliance.benchmark.cis", "panel_type": "benchmark", "title": "CIS", "status": "complete"},
+			"aws_compliance.benchmark.cis_s3": map[string]interface{}{"name": "aws_compliance.benchmark.cis_s3", "panel_type": "benchmark", "title": "S3", "status": "complete"},
+			control: map[string]interface{}{
+				"name": control, "panel_type": "control", "title": "Versioning enabled", "status": "complete", "severity": "high",
+				"tags": map[string]interface{}{"cis": "true"}, "sql": "select arn as resource from aws_s3_bucket",
+				"data": map[string]interface{}{
+					"columns": []interface{}{map[string]interface{}{"name": "resource"}, map[string]interface{}{"name": "status"}, map[string]interface{}{"name": "reason"}, map[string]interface{}{"name": "region"}},
+					"rows":    controlRows,
+				},
+			},
+			"aws_compliance.chart.buckets": map[string]interface{}{
+				"name": "aws_compliance.chart.buckets", "panel_type": "chart", "display_type": "bar", "status": "error", "error": "timeout",
+				"data": map[string]interface{}{"rows": []interface{}{map[string]interface{}{"region": "us-east-1", "count": 2}}},
+			},
+		},
+	}
+}
+
+func TestListSnapshotPanels(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.set("user/jane/workspace/dev/snapshot/snap_1.json", testSnapshotData(map[string]string{"bucket_a": "ok", "bucket_b": "alarm"}))
+
+	panels, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot_panel", quals: []*quals.Qual{
+		equalsQual("snapshot_id", "snap_1"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(panels) != 4 {
+		t.Fatalf("expected 4 panels, got %d", len(panels))
+	}
+	for _, panel := range panels {
+		if panel["workspace_handle"] != "dev" {
+			t.Errorf("expected panels of dev, got %v", panel)
+		}
+		if panel["name"] == "aws_compliance.chart.buckets" && (panel["display_type"] != "bar" || panel["error"] != "timeout" || panel["row_count"] != int64(1)) {
+			t.Errorf("unexpected chart panel %v", panel)
+		}
+	}
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot_panel_row", quals: []*quals.Qual{
+		equalsQual("snapshot_id", "snap_1"),
+		equalsQual("panel_type", "control"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 control rows, got %d", len(rows))
+	}
+	for _, row := range rows {
+		if row["row_number"] == int64(2) && !reflect.DeepEqual(row["data"], map[string]interface{}{"resource": "bucket_b", "status": "alarm", "reason": "bucket_b is alarm", "region": "us-east-1"}) {
+			t.Errorf("unexpected row %v", row)
+		}
+	}
+}
+
+func TestListSnapshotPanelsSkipsForbiddenWorkspaces(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.set("user/jane/workspace/dev/snapshot/snap_1.json", testSnapshotData(map[string]string{"bucket_a": "ok"}))
+	m.fail("org/acme/workspace/prod/snapshot/snap_1", http.StatusForbidden)
+
+	// without a workspace qual, every workspace is asked for the snapshot
+	panels, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot_panel", quals: []*quals.Qual{
+		equalsQual("snapshot_id", "snap_1"),
+	}})
+	if err != nil {
+		t.Fatalf("expected the forbidden workspace to be skipped, got %v", err)
+	}
+	if len(panels) != 4 {
+		t.Errorf("expected 4 panels, got %d", len(panels))
+	}
+
+	// a forbidden workspace which was asked for is an error
+	m.fail("org/acme/workspace/prod/snapshot/snap_1", http.StatusForbidden)
+	_, err = m.list(tableQuery{table: "steampipecloud_workspace_snapshot_panel", quals: []*quals.Qual{
+		equalsQual("identity_handle", "acme"),
+		equalsQual("workspace_handle", "prod"),
+		equalsQual("snapshot_id", "snap_1"),
+	}})
+	if !isAPIError(err, apiErrorForbidden) {
+		t.Errorf("expected a forbidden error, got %v", err)
+	}
+}
+
+func TestListSnapshotControlResults(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.set("user/jane/workspace/dev/snapshot/snap_1.json", testSnapshotData(map[string]string{"bucket_a": "ok", "bucket_b": "alarm"}))
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot_control_result", quals: []*quals.Qual{
+		equalsQual("identity_handle", "jane"),
+		equalsQual("workspace_handle", "dev"),
+		equalsQual("snapshot_id", "snap_1"),
+		equalsQual("status", "alarm"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 alarm, got %d", len(rows))
+	}
+
+	result := rows[0]
+	if result["resource"] != "bucket_b" || result["control_name"] != "aws_compliance.control.s3_bucket_versioning_enabled" || result["severity"] != "high" {
+		t.Errorf("unexpected result %v", result)
+	}
+	if result["benchmark_name"] != "aws_compliance.benchmark.cis_s3" || !reflect.DeepEqual(result["benchmark_path"], []string{"aws_compliance.benchmark.cis", "aws_compliance.benchmark.cis_s3"}) {
+		t.Errorf("unexpected benchmark %v", result)
+	}
+	if !reflect.DeepEqual(result["dimensions"], map[string]interface{}{"region": "us-east-1"}) {
+		t.Errorf("unexpected dimensions %v", result["dimensions"])
+	}
+}
+
+func TestSnapshotDataDownload(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.set("user/jane/workspace/dev/snapshot/snap_1.json", testSnapshotData(map[string]string{"bucket_a": "ok"}))
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if row["id"] != "snap_1" {
+			continue
+		}
+		payload, ok := row["payload"].(openapi.WorkspaceSnapshotData)
+		if !ok || payload.Layout.Name != "aws_compliance.benchmark.cis" {
+			t.Errorf("unexpected payload %v", row["payload"])
+		}
+		// data is the payload encoded as a JSON string, as it always was
+		var data openapi.WorkspaceSnapshotData
+		if encoded, ok := row["data"].(string); !ok || json.Unmarshal([]byte(encoded), &data) != nil || data.Layout.Name != "aws_compliance.benchmark.cis" {
+			t.Errorf("unexpected data %v", row["data"])
+		}
+		if size, ok := row["data_size_bytes"].(int64); !ok || size <= 0 {
+			t.Errorf("unexpected data_size_bytes %v", row["data_size_bytes"])
+		}
+	}
+
+	// both sides of the diff share a single download of the snapshot
+	diffs, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot_diff", quals: []*quals.Qual{
+		equalsQual("from_snapshot_id", "snap_1"),
+		equalsQual("to_snapshot_id", "snap_1"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("expected no differences, got %d", len(diffs))
+	}
+	// the payload, text and size requests of the first query, and the payload
+	// download of the diff
+	if count := m.requestCount("user/jane/workspace/dev/snapshot/snap_1.json"); count != 4 {
+		t.Errorf("expected 4 requests for the snapshot, got %d", count)
+	}
+
+	maxSize := 100
+	m.connectionConfig.MaxSnapshotSizeBytes = &maxSize
+	_, err = m.list(tableQuery{table: "steampipecloud_workspace_snapshot_panel", quals: []*quals.Qual{
+		equalsQual("snapshot_id", "snap_1"),
+	}})
+	if err == nil || !strings.Contains(err.Error(), "max_snapshot_size_bytes") {
+		t.Errorf("expected the snapshot to be too large, got %v", err)
+	}
+}
+
+func TestSnapshotDataFormats(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.set("user/jane/workspace/dev/snapshot/snap_1.csv", []byte("resource,status\nbucket_a,ok\n"))
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot", quals: []*quals.Qual{
+		equalsQual("id", "snap_1"),
+		equalsQual("format", "csv"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if row["id"] != "snap_1" {
+			continue
+		}
+		if row["format"] != "csv" || row["content_type"] != "text/csv" || row["data_text"] != "resource,status\nbucket_a,ok\n" {
+			t.Errorf("unexpected export %v", row)
+		}
+	}
+	if where := m.where("user/jane/workspace/dev/snapshot"); strings.Contains(where, "format") {
+		t.Errorf("expected the format not to be filtered on, got %q", where)
+	}
+
+	_, err = m.list(tableQuery{table: "steampipecloud_workspace_snapshot", quals: []*quals.Qual{
+		equalsQual("format", "pdf"),
+	}})
+	if err == nil || !strings.Contains(err.Error(), "unsupported snapshot format") {
+		t.Errorf("expected an unsupported format error, got %v", err)
+	}
+}
+
+func TestSnapshotDataSizeUnavailable(t *testing.T) {
+	m := newFixtureCloud(t)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	p := Plugin(ctx)
+	table := p.TableMap["steampipecloud_workspace_snapshot"]
+	table.Plugin = p
+	d, err := m.newQueryData(t, table, table.List.KeyColumns, tableQuery{table: table.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &plugin.HydrateData{Item: openapi.WorkspaceSnapshot{Id: "snap_1", IdentityId: "u_jane", WorkspaceId: "w_dev"}}
+
+	// called directly, as the harness would ignore the 404
+	m.fail("user/jane/workspace/dev/snapshot/snap_1.json", http.StatusMethodNotAllowed)
+	for _, description := range []string{"HEAD not allowed", "not found"} {
+		size, err := getSnapshotDataSize(ctx, d, h)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", description, err)
+		}
+		if size != (*int64)(nil) {
+			t.Errorf("%s: expected a null size, got %v", description, size)
+		}
+	}
+}
+
+func TestSnapshotDataLargePayloadNotCached(t *testing.T) {
+	m := newFixtureCloud(t)
+	data := testSnapshotData(map[string]string{"bucket_a": "ok"})
+	data.Panels["aws_compliance.chart.buckets"].(map[string]interface{})["error"] = strings.Repeat("x", maxCachedSnapshotSizeBytes)
+	m.set("user/jane/workspace/dev/snapshot/snap_1.json", data)
+
+	// both sides of the diff download the snapshot, as it is not cached
+	_, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot_diff", quals: []*quals.Qual{
+		equalsQual("from_snapshot_id", "snap_1"),
+		equalsQual("to_snapshot_id", "snap_1"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := m.requestCount("user/jane/workspace/dev/snapshot/snap_1.json"); count != 2 {
+		t.Errorf("expected the large snapshot to be downloaded for each side of the diff, got %d requests", count)
+	}
+}
+
+func TestListSnapshotControlResultsSharedControl(t *testing.T) {
+	m := newFixtureCloud(t)
+	data := testSnapshotData(map[string]string{"bucket_a": "ok", "bucket_b": "alarm"})
+	// the control is also in a second benchmark
+	control := "aws_compliance.control.s3_bucket_versioning_enabled"
+	children := append(*data.Layout.Children, openapi.WorkspaceSnapshotDataLayout{Name: "aws_compliance.benchmark.cis_logging", PanelType: "benchmark", Children: &[]openapi.WorkspaceSnapshotDataLayout{
+		{Name: control, PanelType: "control"},
+	}})
+	data.Layout.Children = &children
+	m.set("user/jane/workspace/dev/snapshot/snap_1.json", data)
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot_control_result", quals: []*quals.Qual{
+		equalsQual("snapshot_id", "snap_1"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+	for _, row := range rows {
+		counts[row["benchmark_name"].(string)]++
+	}
+	expected := map[string]int{"aws_compliance.benchmark.cis_s3": 2, "aws_compliance.benchmark.cis_logging": 2}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("expected results under both benchmarks %v, got %v", expected, counts)
+	}
+}
+
+func TestListSnapshotDiffs(t *testing.T) {
+	m := newFixtureCloud(t)
+	m.set("user/jane/workspace/dev/snapshot/snap_1.json", testSnapshotData(map[string]string{"bucket_a": "ok", "bucket_b": "ok", "bucket_c": "alarm"}))
+	to := testSnapshotData(map[string]string{"bucket_a": "ok", "bucket_b": "alarm", "bucket_d": "ok"})
+	chart := to.Panels["aws_compliance.chart.buckets"].(map[string]interface{})
+	chart["data"] = map[string]interface{}{"rows": []interface{}{map[string]interface{}{"region": "us-east-1", "count": 3}}}
+	m.set("user/jane/workspace/dev/snapshot/snap_2.json", to)
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot_diff", quals: []*quals.Qual{
+		equalsQual("identity_handle", "jane"),
+		equalsQual("workspace_handle", "dev"),
+		equalsQual("from_snapshot_id", "snap_1"),
+		equalsQual("to_snapshot_id", "snap_2"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := map[string]string{}
+	for _, row := range rows {
+		key := row["item_type"].(string) + ":" + row["panel_name"].(string)
+		if resource, ok := row["resource"].(string); ok {
+			key += ":" + resource
+		}
+		changes[key+":"+row["change"].(string)] = fmt.Sprint(row["from_status"], "->", row["to_status"])
+	}
+
+	control := "control_result:aws_compliance.control.s3_bucket_versioning_enabled:"
+	expected := map[string]string{
+		control + "bucket_b:changed":                     "ok->alarm",
+		control + "bucket_d:added":                       "<nil>->ok",
+		control + "bucket_c:removed":                     "alarm-><nil>",
+		"panel_row:aws_compliance.chart.buckets:removed": "<nil>-><nil>",
+		"panel_row:aws_compliance.chart.buckets:added":   "<nil>-><nil>",
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, changes)
+	}
+}
+
+func TestListSnapshotDiffsWithoutResources(t *testing.T) {
+	m := newFixtureCloud(t)
+	// results without a resource, for a control which is in two benchmarks
+	withoutResources := func(statuses ...string) openapi.WorkspaceSnapshotData {
+		data := testSnapshotData(nil)
+		control := "aws_compliance.control.s3_bucket_versioning_enabled"
+		var rows []interface{}
+		for _, status := range statuses {
+			rows = append(rows, map[string]interface{}{"status": status, "reason": "account is " + status})
+		}
+		data.Panels[control].(map[string]interface{})["data"].(map[string]interface{})["rows"] = rows
+		children := append(*data.Layout.Children, openapi.WorkspaceSnapshotDataLayout{Name: "aws_compliance.benchmark.cis_logging", PanelType: "benchmark", Children: &[]openapi.WorkspaceSnapshotDataLayout{
+			{Name: control, PanelType: "control"},
+		}})
+		data.Layout.Children = &children
+		return data
+	}
+	m.set("user/jane/workspace/dev/snapshot/snap_1.json", withoutResources("ok", "ok"))
+	m.set("user/jane/workspace/dev/snapshot/snap_2.json", withoutResources("ok", "alarm", "ok"))
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot_diff", quals: []*quals.Qual{
+		equalsQual("identity_handle", "jane"),
+		equalsQual("workspace_handle", "dev"),
+		equalsQual("from_snapshot_id", "snap_1"),
+		equalsQual("to_snapshot_id", "snap_2"),
+		equalsQual("item_type", "control_result"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := map[string]string{}
+	for _, row := range rows {
+		benchmarkPath := row["benchmark_path"].([]string)
+		key := benchmarkPath[len(benchmarkPath)-1] + ":" + row["change"].(string)
+		changes[key] = fmt.Sprint(row["from_status"], "->", row["to_status"])
+	}
+	expected := map[string]string{
+		"aws_compliance.benchmark.cis_s3:changed":      "ok->alarm",
+		"aws_compliance.benchmark.cis_s3:added":        "<nil>->ok",
+		"aws_compliance.benchmark.cis_logging:changed": "ok->alarm",
+		"aws_compliance.benchmark.cis_logging:added":   "<nil>->ok",
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, changes)
+	}
+}
+
+func TestListSnapshotDiffsDuplicateResources(t *testing.T) {
+	m := newFixtureCloud(t)
+	// the same resource in more than one region
+	withRegions := func(statuses map[string]string) openapi.WorkspaceSnapshotData {
+		data := testSnapshotData(nil)
+		var rows []interface{}
+		for _, region := range []string{"eu-west-1", "us-east-1"} {
+			if status, ok := statuses[region]; ok {
+				rows = append(rows, map[string]interface{}{"resource": "bucket_a", "status": status, "reason": "bucket_a is " + status, "region": region})
+			}
+		}
+		data.Panels["aws_compliance.control.s3_bucket_versioning_enabled"].(map[string]interface{})["data"].(map[string]interface{})["rows"] = rows
+		return data
+	}
+	m.set("user/jane/workspace/dev/snapshot/snap_1.json", withRegions(map[string]string{"us-east-1": "ok", "eu-west-1": "alarm"}))
+	m.set("user/jane/workspace/dev/snapshot/snap_2.json", withRegions(map[string]string{"us-east-1": "ok"}))
+
+	rows, err := m.list(tableQuery{table: "steampipecloud_workspace_snapshot_diff", quals: []*quals.Qual{
+		equalsQual("identity_handle", "jane"),
+		equalsQual("workspace_handle", "dev"),
+		equalsQual("from_snapshot_id", "snap_1"),
+		equalsQual("to_snapshot_id", "snap_2"),
+		equalsQual("item_type", "control_result"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected only the eu-west-1 result to be removed, got %v", rows)
+	}
+	if rows[0]["change"] != "removed" || rows[0]["from_status"] != "alarm" {
+		t.Errorf("expected the eu-west-1 result to be removed, got %v", rows[0])
+	}
+}
+
+func TestTables(t *testing.T) {
+	cases := []struct {
+		table string
+		// list
+		listQuals []*quals.Qual
+		listRows  int
+		// get, skipped if getQuals is nil
+		getQuals  []*quals.Qual
+		getColumn string
+		getValue  string
+	}{
+		{
+			table:     "steampipecloud_audit_log",
+			listQuals: []*quals.Qual{equalsQual("identity_handle", "jane")},
+			listRows:  3,
+		},
+		{
+			table:     "steampipecloud_connection",
+			listRows:  2,
+			getQuals:  []*quals.Qual{equalsQual("identity_handle", "jane"), equalsQual("handle", "aws")},
+			getColumn: "id",
+			getValue:  "c_aws",
+		},
+		{
+			table:     "steampipecloud_organization",
+			listRows:  1,
+			getQuals:  []*quals.Qual{equalsQual("handle", "acme")},
+			getColumn: "id",
+			getValue:  "o_acme",
+		},
+		{
+			table:     "steampipecloud_organization_member",
+			listRows:  1,
+			getQuals:  []*quals.Qual{equalsQual("org_handle", "acme"), equalsQual("user_handle", "jane")},
+			getColumn: "id",
+			getValue:  "om_1",
+		},
+		{
+			table:     "steampipecloud_organization_workspace_member",
+			listRows:  1,
+			getQuals:  []*quals.Qual{equalsQual("org_handle", "acme"), equalsQual("workspace_handle", "prod"), equalsQual("user_handle", "jane")},
+			getColumn: "id",
+			getValue:  "owm_1",
+		},
+		{
+			table:     "steampipecloud_process",
+			listRows:  1,
+			getQuals:  []*quals.Qual{equalsQual("identity_handle", "jane"), equalsQual("id", "sp_2")},
+			getColumn: "id",
+			getValue:  "sp_2",
+		},
+		{
+			table:     "steampipecloud_token",
+			listRows:  1,
+			getQuals:  []*quals.Qual{equalsQual("id", "tok_1")},
+			getColumn: "last4",
+			getValue:  "abcd",
+		},
+		{
+			table:    "steampipecloud_user",
+			listRows: 1,
+		},
+		{
+			table:    "steampipecloud_user_email",
+			listRows: 1,
+		},
+		{
+			table:    "steampipecloud_user_preferences",
+			listRows: 1,
+		},
+		{
+			table:     "steampipecloud_workspace",
+			listRows:  3,
+			getQuals:  []*quals.Qual{equalsQual("identity_handle", "acme"), equalsQual("handle", "prod")},
+			getColumn: "id",
+			getValue:  "w_prod",
+		},
+		{
+			table:     "steampipecloud_workspace_aggregator",
+			listRows:  1,
+			getQuals:  []*quals.Qual{equalsQual("identity_handle", "acme"), equalsQual("workspace_handle", "prod"), equalsQual("handle", "all_aws")},
+			getColumn: "id",
+			getValue:  "ag_1",
+		},
+		{
+			table:    "steampipecloud_workspace_connection",
+			listRows: 1,
+		},
+		{
+			table:    "steampipecloud_workspace_db_log",
+			listRows: 3,
+		},
+		{
+			table:     "steampipecloud_workspace_mod",
+			listRows:  1,
+			getQuals:  []*quals.Qual{equalsQual("identity_id", "u_jane"), equalsQual("workspace_id", "w_dev"), equalsQual("alias", "aws_compliance")},
+			getColumn: "id",
+			getValue:  "m_1",
+		},
+		{
+			table:     "steampipecloud_workspace_mod_variable",
+			listQuals: []*quals.Qual{equalsQual("workspace_id", "w_dev"), equalsQual("mod_alias", "aws_compliance")},
+			listRows:  1,
+		},
+		{
+			table:     "steampipecloud_workspace_pipeline",
+			listRows:  1,
+			getQuals:  []*quals.Qual{equalsQual("identity_handle", "acme"), equalsQual("workspace_handle", "prod"), equalsQual("id", "p_1")},
+			getColumn: "pipeline",
+			getValue:  "snapshot.dashboard",
+		},
+		{
+			table:     "steampipecloud_workspace_process",
+			listRows:  1,
+			getQuals:  []*quals.Qual{equalsQual("identity_handle", "acme"), equalsQual("workspace_handle", "prod"), equalsQual("id", "sp_1")},
+			getColumn: "workspace_handle",
+			getValue:  "prod",
+		},
+		{
+			table:     "steampipecloud_workspace_snapshot",
+			listRows:  3,
+			getQuals:  []*quals.Qual{equalsQual("identity_handle", "jane"), equalsQual("workspace_handle", "dev"), equalsQual("id", "snap_1")},
+			getColumn: "identity_handle",
+			getValue:  "jane",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.table, func(t *testing.T) {
+			m := newFixtureCloud(t)
+
+			rows, err := m.list(tableQuery{table: tc.table, quals: tc.listQuals})
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			if len(rows) != tc.listRows {
+				t.Errorf("list: expected %d rows, got %d", tc.listRows, len(rows))
+			}
+
+			if tc.getQuals == nil {
+				return
+			}
+			row, err := m.get(tableQuery{table: tc.table, quals: tc.getQuals})
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if row == nil {
+				t.Fatal("get: expected a row")
+			}
+			if row[tc.getColumn] != tc.getValue {
+				t.Errorf("get: expected %s %q, got %v", tc.getColumn, tc.getValue, row[tc.getColumn])
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package steampipecloud
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tokenCommandTimeout is the time allowed for a token_command to run.
+const tokenCommandTimeout = 30 * time.Second
+
+// resolveToken returns the API token for the connection, taken from the first
+// source which is set, in order:
+//
+//  1. the token config argument
+//  2. the file named by the token_file config argument
+//  3. the output of the token_command config argument
+//  4. the STEAMPIPE_CLOUD_TOKEN or PIPES_TOKEN environment variable,
+//     preferring PIPES_TOKEN for Turbot Pipes hosts
+//  5. the token saved for the host by "steampipe login"
+//
+// If a configured source fails, e.g. the token file does not exist, the
+// error names the source rather than falling back to the next one.
+func resolveToken(config steampipecloudConfig, host string) (string, error) {
+	if config.Token != nil && *config.Token != "" {
+		return *config.Token, nil
+	}
+
+	if config.TokenFile != nil && *config.TokenFile != "" {
+		return readTokenFile(*config.TokenFile)
+	}
+
+	if len(config.TokenCommand) > 0 {
+		return runTokenCommand(config.TokenCommand)
+	}
+
+	// Prefer the environment variable for the platform of the host
+	envVars := []string{"STEAMPIPE_CLOUD_TOKEN", "PIPES_TOKEN"}
+	if hostPlatform(host) == platformTurbotPipes {
+		envVars = []string{"PIPES_TOKEN", "STEAMPIPE_CLOUD_TOKEN"}
+	}
+	for _, envVar := range envVars {
+		if token := os.Getenv(envVar); token != "" {
+			return token, nil
+		}
+	}
+
+	loginTokenPath, err := steampipeLoginTokenPath(host)
+	if err != nil {
+		return "", fmt.Errorf("could not locate the Steampipe CLI login token: %v", err)
+	}
+	if _, err := os.Stat(loginTokenPath); err == nil {
+		token, err := readTokenFile(loginTokenPath)
+		if err != nil {
+			return "", fmt.Errorf("Steampipe CLI login token: %v", err)
+		}
+		return token, nil
+	}
+
+	return "", fmt.Errorf("no API token found. Tried the 'token', 'token_file' and 'token_command' connection config arguments, the STEAMPIPE_CLOUD_TOKEN and PIPES_TOKEN environment variables and the Steampipe CLI login token %s. Edit your connection configuration file and then restart Steampipe", loginTokenPath)
+}
+
+// usesTokenCommand returns true if the token is taken from token_command,
+// i.e. it is set and neither token nor token_file is.
+func usesTokenCommand(config steampipecloudConfig) bool {
+	if config.Token != nil && *config.Token != "" {
+		return false
+	}
+	if config.TokenFile != nil && *config.TokenFile != "" {
+		return false
+	}
+	return len(config.TokenCommand) > 0
+}
+
+func readTokenFile(path string) (string, error) {
+	path, err := expandHomeDir(path)
+	if err != nil {
+		return "", fmt.Errorf("token_file %s: %v", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("token_file %s: %v", path, err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token_file %s is empty", path)
+	}
+	return token, nil
+}
+
+// tokenFileVersion returns the modification time and size of a token file,
+// or "" if it cannot be read, so that a client is rebuilt once the file has
+// been rewritten with a new token.
+func tokenFileVersion(path string) string {
+	path, err := expandHomeDir(path)
+	if err != nil {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// runTokenCommand runs a credential helper, which must print the token to stdout.
+func runTokenCommand(command []string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("token_command %s: timed out after %s", command[0], tokenCommandTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("token_command %s: %v: %s", command[0], err, msg)
+		}
+		return "", fmt.Errorf("token_command %s: %v", command[0], err)
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", fmt.Errorf("token_command %s returned no token", command[0])
+	}
+	return token, nil
+}
+
+// steampipeLoginTokenPath returns the path of the token saved by
+// "steampipe login" for the host, e.g. ~/.steampipe/internal/cloud.steampipe.io.tptt.
+func steampipeLoginTokenPath(host string) (string, error) {
+	parsedURL, err := parseEndpointURL(host)
+	if err != nil {
+		return "", err
+	}
+	hostname := parsedURL.Hostname()
+
+	installDir := os.Getenv("STEAMPIPE_INSTALL_DIR")
+	if installDir == "" {
+		installDir = "~/.steampipe"
+	}
+	installDir, err = expandHomeDir(installDir)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(installDir, "internal", hostname+".tptt"), nil
+}
+
+func expandHomeDir(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
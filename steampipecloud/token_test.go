@@ -0,0 +1,70 @@
+package steampipecloud
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestResolveToken(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("token_command tests use sh")
+	}
+
+	installDir := t.TempDir()
+	t.Setenv("STEAMPIPE_INSTALL_DIR", installDir)
+	if err := os.MkdirAll(filepath.Join(installDir, "internal"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(installDir, "internal", "cloud.steampipe.io.tptt"), []byte("spt_login\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("  spt_file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	missingFile := filepath.Join(t.TempDir(), "missing")
+
+	str := func(s string) *string { return &s }
+
+	cases := []struct {
+		name     string
+		config   steampipecloudConfig
+		env      string
+		host     string
+		expected string
+		err      string
+	}{
+		{name: "token", config: steampipecloudConfig{Token: str("spt_config"), TokenFile: &tokenFile}, env: "spt_env", expected: "spt_config"},
+		{name: "token_file", config: steampipecloudConfig{TokenFile: &tokenFile, TokenCommand: []string{"sh", "-c", "echo spt_command"}}, expected: "spt_file"},
+		{name: "missing token_file", config: steampipecloudConfig{TokenFile: &missingFile}, env: "spt_env", err: "token_file " + missingFile},
+		{name: "token_command", config: steampipecloudConfig{TokenCommand: []string{"sh", "-c", "echo spt_command"}}, env: "spt_env", expected: "spt_command"},
+		{name: "failed token_command", config: steampipecloudConfig{TokenCommand: []string{"sh", "-c", "echo denied >&2; exit 1"}}, err: "token_command sh: exit status 1: denied"},
+		{name: "empty token_command", config: steampipecloudConfig{TokenCommand: []string{"true"}}, err: "token_command true returned no token"},
+		{name: "env", env: "spt_env", expected: "spt_env"},
+		{name: "login", expected: "spt_login"},
+		{name: "no login for host", host: "https://pipes.example.com", err: "no API token found"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("STEAMPIPE_CLOUD_TOKEN", tc.env)
+			token, err := resolveToken(tc.config, tc.host)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token != tc.expected {
+				t.Errorf("expected token %q, got %q", tc.expected, token)
+			}
+		})
+	}
+}